@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// parseChecksumAlgorithm returns the upper case checksum algorithm(CRC32, CRC32C, SHA1 or SHA256)
+func parseChecksumAlgorithm(algorithm string) (string, error) {
+	algorithm = strings.ToUpper(algorithm)
+	if !slices.Contains(s3.ChecksumAlgorithm_Values(), algorithm) {
+		return "", fmt.Errorf("invalid checksum-algorithm %s(%s)", algorithm, strings.Join(s3.ChecksumAlgorithm_Values(), ","))
+	}
+	return algorithm, nil
+}
+
+func newChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case s3.ChecksumAlgorithmCrc32:
+		return crc32.NewIEEE()
+	case s3.ChecksumAlgorithmCrc32c:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case s3.ChecksumAlgorithmSha1:
+		return sha1.New()
+	default:
+		return sha256.New()
+	}
+}
+
+// computeChecksum returns the base64 encoded checksum(x-amz-checksum-*) of the rest content of r,
+// r is seeked back to where it was.
+func computeChecksum(algorithm string, r io.ReadSeeker) (string, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	h := newChecksumHash(algorithm)
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// setChecksum sets the Checksum<algorithm> field of a request input or CompletedPart to value
+func setChecksum(v interface{}, algorithm, value string) {
+	awsutil.SetValueAtPath(v, "Checksum"+algorithm, value)
+}
+
+// checksumOf returns the first checksum of a GetObject or HeadObject output or a Part, empty if there is no checksum
+func checksumOf(out interface{}) (algorithm, value string) {
+	for _, algorithm := range s3.ChecksumAlgorithm_Values() {
+		values, err := awsutil.ValuesAtPath(out, "Checksum"+algorithm)
+		if err != nil || len(values) == 0 {
+			continue
+		}
+		if v, ok := values[0].(*string); ok && aws.StringValue(v) != "" {
+			return algorithm, aws.StringValue(v)
+		}
+	}
+	return "", ""
+}
+
+// checkChecksum reads r to the end and compares its checksum with want of algorithm
+func checkChecksum(algorithm, want string, r io.Reader) error {
+	h := newChecksumHash(algorithm)
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if got := base64.StdEncoding.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("checksum %s mismatch, expect %s, got %s", algorithm, want, got)
+	}
+	return nil
+}
+
+// downloadChecksum returns the checksum to validate the download of key with, empty if it can not be validated:
+// no checksum is stored, or it is the composite checksum(<checksum>-<parts>) of a multipart Object.
+func (sc *S3Cli) downloadChecksum(key, algorithm, value string) (string, string) {
+	if algorithm == "" || strings.Contains(value, "-") {
+		if sc.verboseOutput() {
+			fmt.Printf("%s has no full object checksum to validate\n", key)
+		}
+		return "", ""
+	}
+	return algorithm, value
+}
+
+// headChecksum returns the stored checksum of Object bucket/key
+func (sc *S3Cli) headChecksum(ctx context.Context, bucket, key, version string) (algorithm, value string, err error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(key),
+		ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.HeadObjectRequest(input)
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return "", "", fmt.Errorf("head object %s failed: %w", key, err)
+	}
+	algorithm, value = checksumOf(resp)
+	return algorithm, value, nil
+}
+
+// checksumRequestOption returns a request.Option of s3manager.Uploader, which only sends the algorithm:
+// PutObject and UploadPart are sent with the checksum of the body, CompleteMultipartUpload is sent
+// with the checksums of all parts.
+func checksumRequestOption(algorithm string) request.Option {
+	mu := sync.Mutex{}
+	parts := map[int64]string{}
+	return func(r *request.Request) {
+		r.Handlers.Build.PushFront(func(r *request.Request) {
+			switch input := r.Params.(type) {
+			case *s3.PutObjectInput:
+				if input.Body == nil {
+					return
+				}
+				value, err := computeChecksum(algorithm, input.Body)
+				if err != nil {
+					r.Error = err
+					return
+				}
+				input.ChecksumAlgorithm = aws.String(algorithm)
+				setChecksum(input, algorithm, value)
+			case *s3.UploadPartInput:
+				value, err := computeChecksum(algorithm, input.Body)
+				if err != nil {
+					r.Error = err
+					return
+				}
+				input.ChecksumAlgorithm = aws.String(algorithm)
+				setChecksum(input, algorithm, value)
+				mu.Lock()
+				parts[aws.Int64Value(input.PartNumber)] = value
+				mu.Unlock()
+			case *s3.CompleteMultipartUploadInput:
+				if input.MultipartUpload == nil {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for _, p := range input.MultipartUpload.Parts {
+					setChecksum(p, algorithm, parts[aws.Int64Value(p.PartNumber)])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/johannesboyne/gofakes3"
+)
+
+func Test_computeChecksum(t *testing.T) {
+	data := []byte("123456789")
+	sha1Sum := sha1.Sum(data)
+	sha256Sum := sha256.Sum256(data)
+	cases := map[string]string{
+		"CRC32":  "y/Q5Jg==", // 0xcbf43926
+		"CRC32C": "4waSgw==", // 0xe3069283
+		"SHA1":   base64.StdEncoding.EncodeToString(sha1Sum[:]),
+		"SHA256": base64.StdEncoding.EncodeToString(sha256Sum[:]),
+	}
+	for algorithm, want := range cases {
+		r := bytes.NewReader(append([]byte("skip"), data...))
+		r.Seek(4, io.SeekStart)
+		got, err := computeChecksum(algorithm, r)
+		if err != nil || got != want {
+			t.Errorf("computeChecksum(%s) expect: %s, got: %s, %v", algorithm, want, got, err)
+		}
+		if offset, _ := r.Seek(0, io.SeekCurrent); offset != 4 {
+			t.Errorf("computeChecksum(%s) expect reader at 4, got: %d", algorithm, offset)
+		}
+	}
+}
+
+func Test_parseChecksumAlgorithm(t *testing.T) {
+	for _, v := range []string{"crc32", "CRC32C", "sha1", "Sha256"} {
+		if got, err := parseChecksumAlgorithm(v); err != nil || got != strings.ToUpper(v) {
+			t.Errorf("parseChecksumAlgorithm(%s) expect: %s, got: %s, %v", v, strings.ToUpper(v), got, err)
+		}
+	}
+	if _, err := parseChecksumAlgorithm("md5"); err == nil {
+		t.Errorf("parseChecksumAlgorithm(md5) expect error")
+	}
+}
+
+func Test_checksumRequestOption(t *testing.T) {
+	opt := checksumRequestOption(s3.ChecksumAlgorithmCrc32)
+	part := []byte("123456789")
+	req, _ := s3cliTest.Client.UploadPartRequest(&s3.UploadPartInput{
+		Body:       bytes.NewReader(part),
+		Bucket:     aws.String(testBucketName),
+		Key:        aws.String("checksumRequestOption"),
+		PartNumber: aws.Int64(1),
+		UploadId:   aws.String("uid"),
+	})
+	req.ApplyOptions(opt)
+	if err := req.Build(); err != nil {
+		t.Fatalf("UploadPart Build failed: %s", err)
+	}
+	if got := req.HTTPRequest.Header.Get("x-amz-checksum-crc32"); got != "y/Q5Jg==" {
+		t.Errorf("UploadPart expect header x-amz-checksum-crc32: y/Q5Jg==, got: %s", got)
+	}
+
+	input := &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(testBucketName),
+		Key:      aws.String("checksumRequestOption"),
+		UploadId: aws.String("uid"),
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: []*s3.CompletedPart{{ETag: aws.String("etag"), PartNumber: aws.Int64(1)}},
+		},
+	}
+	req, _ = s3cliTest.Client.CompleteMultipartUploadRequest(input)
+	req.ApplyOptions(opt)
+	if err := req.Build(); err != nil {
+		t.Fatalf("CompleteMultipartUpload Build failed: %s", err)
+	}
+	if got := aws.StringValue(input.MultipartUpload.Parts[0].ChecksumCRC32); got != "y/Q5Jg==" {
+		t.Errorf("CompleteMultipartUpload expect part checksum y/Q5Jg==, got: %s", got)
+	}
+
+	sc := s3cliTest
+	sc.checksum = s3.ChecksumAlgorithmSha256
+	content := bytes.Repeat(part, 1<<20) // 9MB, 2 parts
+	if _, err := sc.sendMPU(context.Background(), testBucketName, "checksumRequestOption", "", 5<<20, 2, bytes.NewReader(content), nil); err != nil {
+		t.Errorf("sendMPU with checksum failed: %s", err)
+	}
+	if _, err := sc.sendPutObject(context.Background(), testBucketName, "checksumRequestOption", "", nil, false, bytes.NewReader(part)); err != nil {
+		t.Errorf("sendPutObject with checksum failed: %s", err)
+	}
+}
+
+func Test_getObjectChecksum(t *testing.T) {
+	// the fake S3 does not store checksums, respond the checksum of the test case
+	var checksum string
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-amz-checksum-mode") == s3.ChecksumModeEnabled {
+			w.Header().Set("x-amz-checksum-crc32", checksum)
+		}
+		faker.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+
+	key := "getObjectChecksum"
+	content := []byte("123456789")
+	if _, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(content), int64(len(content)), nil); err != nil {
+		t.Fatalf("getObjectChecksum backend PutObject failed: %s", err)
+	}
+	filename := filepath.Join(t.TempDir(), key)
+	cases := []struct {
+		name     string
+		checksum string
+		opt      downloadOptions
+		ok       bool
+	}{
+		{"match", "y/Q5Jg==", downloadOptions{checksum: true}, true},
+		{"mismatch", "AAAAAA==", downloadOptions{checksum: true}, false},
+		{"composite", "AAAAAA==-2", downloadOptions{checksum: true}, true},
+		{"parallel match", "y/Q5Jg==", downloadOptions{checksum: true, partSize: 4, concurrency: 2}, true},
+		{"parallel mismatch", "AAAAAA==", downloadOptions{checksum: true, partSize: 4, concurrency: 2}, false},
+		{"resume match", "y/Q5Jg==", downloadOptions{checksum: true, resume: true}, true},
+		{"resume mismatch", "AAAAAA==", downloadOptions{checksum: true, resume: true}, false},
+	}
+	for _, c := range cases {
+		checksum = c.checksum
+		os.Remove(filename)
+		err := sc.getObject(context.Background(), testBucketName, key, "", "", filename, c.opt)
+		if c.ok && err != nil {
+			t.Errorf("getObject %s failed: %s", c.name, err)
+		}
+		if !c.ok {
+			if err == nil {
+				t.Errorf("getObject %s expect error", c.name)
+			}
+			if _, err := os.Stat(filename); err == nil {
+				t.Errorf("getObject %s expect no local file", c.name)
+			}
+		}
+	}
+}
+
+func Test_mpuChecksum(t *testing.T) {
+	// record the part checksums of UploadPart and CompleteMultipartUpload
+	mu := sync.Mutex{}
+	var partChecksums, completeChecksums int
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("uploadId") != "" {
+			mu.Lock()
+			if r.Method == http.MethodPut && r.Header.Get("x-amz-checksum-sha256") != "" {
+				partChecksums++
+			}
+			if r.Method == http.MethodPost {
+				body, _ := io.ReadAll(r.Body)
+				completeChecksums += strings.Count(string(body), "<ChecksumSHA256>")
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			mu.Unlock()
+		}
+		faker.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+	sc.checksum = s3.ChecksumAlgorithmSha256
+
+	ctx := context.Background()
+	key := "mpuChecksum"
+	content := bytes.Repeat(testObjectContent, 2) // 36 bytes, 3 parts of 16 bytes at most
+	dir := t.TempDir()
+	filename := filepath.Join(dir, key)
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := sc.mpuResume(ctx, testBucketName, key, "", filename, filename+".mpu.json", 16, 2, nil); err != nil {
+		t.Fatalf("mpuResume with checksum failed: %s", err)
+	}
+	if partChecksums != 3 || completeChecksums != 3 {
+		t.Errorf("mpuResume expect 3 part checksums, got: %d UploadPart, %d CompleteMultipartUpload", partChecksums, completeChecksums)
+	}
+
+	partChecksums = 0
+	cmu, err := sc.Client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(testBucketName),
+		Key:               aws.String(key),
+		ChecksumAlgorithm: aws.String(sc.checksum),
+	})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload failed: %s", err)
+	}
+	if err := sc.mpuUpload(ctx, testBucketName, key, aws.StringValue(cmu.UploadId), map[int64]string{1: filename}); err != nil {
+		t.Fatalf("mpuUpload with checksum failed: %s", err)
+	}
+	if partChecksums != 1 {
+		t.Errorf("mpuUpload expect 1 part checksum, got: %d", partChecksums)
+	}
+}
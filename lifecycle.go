@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// maxLifecycleRules is the max number of rules of a BucketLifecycleConfiguration
+const maxLifecycleRules = 1000
+
+// loadLifecycle loads and validates a BucketLifecycleConfiguration from a JSON file
+func loadLifecycle(filename string) (*s3.BucketLifecycleConfiguration, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	cfg := s3.BucketLifecycleConfiguration{}
+	if err := json.NewDecoder(fd).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid lifecycle file %s: %w", filename, err)
+	}
+	if err := validateLifecycle(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid lifecycle file %s: %w", filename, err)
+	}
+	return &cfg, nil
+}
+
+// validateLifecycle checks the rules of cfg the way the server does, so that mistakes are
+// reported before the request
+func validateLifecycle(cfg *s3.BucketLifecycleConfiguration) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if len(cfg.Rules) == 0 {
+		return errors.New("no rules")
+	}
+	if len(cfg.Rules) > maxLifecycleRules {
+		return fmt.Errorf("%d rules, at most %d", len(cfg.Rules), maxLifecycleRules)
+	}
+	var ids []string
+	for i, r := range cfg.Rules {
+		id := aws.StringValue(r.ID)
+		if id == "" {
+			id = fmt.Sprintf("#%d", i+1)
+		} else if len(id) > 255 {
+			return fmt.Errorf("rule %s: ID longer than 255 characters", id)
+		} else if slices.Contains(ids, id) {
+			return fmt.Errorf("rule %s: duplicate ID", id)
+		} else {
+			ids = append(ids, id)
+		}
+		if err := validateLifecycleRule(r); err != nil {
+			return fmt.Errorf("rule %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// validateLifecycleRule checks the status, filter and actions of a LifecycleRule
+func validateLifecycleRule(r *s3.LifecycleRule) error {
+	if !slices.Contains(s3.ExpirationStatus_Values(), aws.StringValue(r.Status)) {
+		return fmt.Errorf("invalid Status %s(%s)", aws.StringValue(r.Status), strings.Join(s3.ExpirationStatus_Values(), " or "))
+	}
+	if r.Prefix != nil && r.Filter != nil {
+		return errors.New("Prefix and Filter are exclusive")
+	}
+	if f := r.Filter; f != nil {
+		n := 0
+		for _, set := range []bool{f.Prefix != nil, f.Tag != nil, f.ObjectSizeGreaterThan != nil, f.ObjectSizeLessThan != nil, f.And != nil} {
+			if set {
+				n++
+			}
+		}
+		if n > 1 {
+			return errors.New("Filter must have only one of Prefix, Tag, ObjectSizeGreaterThan, ObjectSizeLessThan or And")
+		}
+	}
+	if r.Expiration == nil && r.NoncurrentVersionExpiration == nil && r.AbortIncompleteMultipartUpload == nil &&
+		len(r.Transitions) == 0 && len(r.NoncurrentVersionTransitions) == 0 {
+		return errors.New("no action(Expiration, Transitions, NoncurrentVersionExpiration, NoncurrentVersionTransitions or AbortIncompleteMultipartUpload)")
+	}
+	if e := r.Expiration; e != nil {
+		n := 0
+		for _, set := range []bool{e.Date != nil, e.Days != nil, e.ExpiredObjectDeleteMarker != nil} {
+			if set {
+				n++
+			}
+		}
+		if n != 1 {
+			return errors.New("Expiration must have one of Date, Days or ExpiredObjectDeleteMarker")
+		}
+		if e.Days != nil && *e.Days < 1 {
+			return fmt.Errorf("invalid Expiration Days %d", *e.Days)
+		}
+	}
+	if e := r.NoncurrentVersionExpiration; e != nil && aws.Int64Value(e.NoncurrentDays) < 1 {
+		return fmt.Errorf("invalid NoncurrentVersionExpiration NoncurrentDays %d", aws.Int64Value(e.NoncurrentDays))
+	}
+	if a := r.AbortIncompleteMultipartUpload; a != nil && aws.Int64Value(a.DaysAfterInitiation) < 1 {
+		return fmt.Errorf("invalid AbortIncompleteMultipartUpload DaysAfterInitiation %d", aws.Int64Value(a.DaysAfterInitiation))
+	}
+	for _, t := range r.Transitions {
+		if (t.Date == nil) == (t.Days == nil) {
+			return errors.New("Transition must have one of Date or Days")
+		}
+		if !slices.Contains(s3.TransitionStorageClass_Values(), aws.StringValue(t.StorageClass)) {
+			return fmt.Errorf("invalid Transition StorageClass %s", aws.StringValue(t.StorageClass))
+		}
+	}
+	for _, t := range r.NoncurrentVersionTransitions {
+		if !slices.Contains(s3.TransitionStorageClass_Values(), aws.StringValue(t.StorageClass)) {
+			return fmt.Errorf("invalid NoncurrentVersionTransition StorageClass %s", aws.StringValue(t.StorageClass))
+		}
+	}
+	return nil
+}
+
+// lifecycleFilter returns the readable filter of a LifecycleRule, e.g. prefix=logs/ tag=k=v
+func lifecycleFilter(r *s3.LifecycleRule) string {
+	var s []string
+	add := func(prefix *string, tags []*s3.Tag, gt, lt *int64) {
+		if prefix != nil {
+			s = append(s, "prefix="+aws.StringValue(prefix))
+		}
+		for _, t := range tags {
+			s = append(s, fmt.Sprintf("tag=%s=%s", aws.StringValue(t.Key), aws.StringValue(t.Value)))
+		}
+		if gt != nil {
+			s = append(s, fmt.Sprintf("size>%d", *gt))
+		}
+		if lt != nil {
+			s = append(s, fmt.Sprintf("size<%d", *lt))
+		}
+	}
+	add(r.Prefix, nil, nil, nil)
+	if f := r.Filter; f != nil {
+		var tags []*s3.Tag
+		if f.Tag != nil {
+			tags = append(tags, f.Tag)
+		}
+		add(f.Prefix, tags, f.ObjectSizeGreaterThan, f.ObjectSizeLessThan)
+		if a := f.And; a != nil {
+			add(a.Prefix, a.Tags, a.ObjectSizeGreaterThan, a.ObjectSizeLessThan)
+		}
+	}
+	if len(s) == 0 {
+		return "all"
+	}
+	return strings.Join(s, " ")
+}
+
+// lifecycleActions returns the readable actions of a LifecycleRule, e.g. expire=30d abort-mpu=7d
+func lifecycleActions(r *s3.LifecycleRule) string {
+	var s []string
+	if e := r.Expiration; e != nil {
+		switch {
+		case e.Days != nil:
+			s = append(s, fmt.Sprintf("expire=%dd", *e.Days))
+		case e.Date != nil:
+			s = append(s, "expire="+e.Date.Format("2006-01-02"))
+		case aws.BoolValue(e.ExpiredObjectDeleteMarker):
+			s = append(s, "expire=delete-marker")
+		}
+	}
+	for _, t := range r.Transitions {
+		if t.Days != nil {
+			s = append(s, fmt.Sprintf("transition=%dd:%s", *t.Days, aws.StringValue(t.StorageClass)))
+		} else {
+			s = append(s, fmt.Sprintf("transition=%s:%s", t.Date.Format("2006-01-02"), aws.StringValue(t.StorageClass)))
+		}
+	}
+	if e := r.NoncurrentVersionExpiration; e != nil {
+		v := fmt.Sprintf("noncurrent-expire=%dd", aws.Int64Value(e.NoncurrentDays))
+		if e.NewerNoncurrentVersions != nil {
+			v += fmt.Sprintf("(keep %d)", *e.NewerNoncurrentVersions)
+		}
+		s = append(s, v)
+	}
+	for _, t := range r.NoncurrentVersionTransitions {
+		s = append(s, fmt.Sprintf("noncurrent-transition=%dd:%s", aws.Int64Value(t.NoncurrentDays), aws.StringValue(t.StorageClass)))
+	}
+	if a := r.AbortIncompleteMultipartUpload; a != nil {
+		s = append(s, fmt.Sprintf("abort-mpu=%dd", aws.Int64Value(a.DaysAfterInitiation)))
+	}
+	return strings.Join(s, " ")
+}
+
+// getBucketLifecycle prints the lifecycle rules of bucket
+func (sc *S3Cli) getBucketLifecycle(ctx context.Context, bucket string) error {
+	req, out := sc.Client.GetBucketLifecycleConfigurationRequest(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucket),
+	})
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("get bucket lifecycle %s failed: %w", bucket, err)
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			fmt.Println(out.String())
+			return nil
+		}
+		fmt.Printf("%s", jo)
+	} else if sc.verboseOutput() {
+		fmt.Println(out.String())
+	} else if sc.lineOutput() {
+		for _, r := range out.Rules {
+			fmt.Printf("%s\t%s\t%s\t%s\n", aws.StringValue(r.ID), aws.StringValue(r.Status), lifecycleFilter(r), lifecycleActions(r))
+		}
+	} else {
+		for i, r := range out.Rules {
+			id := aws.StringValue(r.ID)
+			if id == "" {
+				id = fmt.Sprintf("#%d", i+1)
+			}
+			fmt.Printf("%s(%s)\n", id, aws.StringValue(r.Status))
+			fmt.Printf("  filter : %s\n", lifecycleFilter(r))
+			fmt.Printf("  actions: %s\n", lifecycleActions(r))
+		}
+	}
+	return nil
+}
+
+// putBucketLifecycle replaces the lifecycle rules of bucket with the BucketLifecycleConfiguration in cfgFile
+func (sc *S3Cli) putBucketLifecycle(ctx context.Context, bucket, cfgFile string) error {
+	cfg, err := loadLifecycle(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	req, out := sc.Client.PutBucketLifecycleConfigurationRequest(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucket),
+		LifecycleConfiguration: cfg,
+	})
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put bucket lifecycle %s failed: %w", bucket, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(out.String())
+	}
+	return nil
+}
+
+// deleteBucketLifecycle removes all lifecycle rules of bucket
+func (sc *S3Cli) deleteBucketLifecycle(ctx context.Context, bucket string) error {
+	req, out := sc.Client.DeleteBucketLifecycleRequest(&s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(bucket),
+	})
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete bucket lifecycle %s failed: %w", bucket, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(out.String())
+	}
+	return nil
+}
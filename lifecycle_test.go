@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func Test_loadLifecycle(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"valid", `{"Rules": [{"ID": "logs", "Status": "Enabled", "Filter": {"Prefix": "logs/"}, "Expiration": {"Days": 30},
+			"NoncurrentVersionExpiration": {"NoncurrentDays": 7}, "AbortIncompleteMultipartUpload": {"DaysAfterInitiation": 3}}]}`, ""},
+		{"not json", `Rules`, "invalid character"},
+		{"no rules", `{"Rules": []}`, "no rules"},
+		{"no status", `{"Rules": [{"ID": "r", "Expiration": {"Days": 1}}]}`, "Status"},
+		{"invalid status", `{"Rules": [{"ID": "r", "Status": "enabled", "Expiration": {"Days": 1}}]}`, "invalid Status"},
+		{"duplicate id", `{"Rules": [{"ID": "r", "Status": "Enabled", "Expiration": {"Days": 1}},
+			{"ID": "r", "Status": "Enabled", "Expiration": {"Days": 2}}]}`, "duplicate ID"},
+		{"no action", `{"Rules": [{"ID": "r", "Status": "Enabled", "Filter": {"Prefix": "a/"}}]}`, "no action"},
+		{"prefix and filter", `{"Rules": [{"Status": "Enabled", "Prefix": "a/", "Filter": {"Prefix": "a/"}, "Expiration": {"Days": 1}}]}`, "rule #1: Prefix and Filter"},
+		{"filter", `{"Rules": [{"ID": "r", "Status": "Enabled", "Filter": {"Prefix": "a/", "Tag": {"Key": "k", "Value": "v"}}, "Expiration": {"Days": 1}}]}`, "only one"},
+		{"expiration", `{"Rules": [{"ID": "r", "Status": "Enabled", "Expiration": {"Days": 1, "ExpiredObjectDeleteMarker": true}}]}`, "Expiration must"},
+		{"expiration days", `{"Rules": [{"ID": "r", "Status": "Enabled", "Expiration": {"Days": 0}}]}`, "Expiration Days"},
+		{"storage class", `{"Rules": [{"ID": "r", "Status": "Enabled", "Transitions": [{"Days": 30, "StorageClass": "COLD"}]}]}`, "StorageClass"},
+	}
+	filename := filepath.Join(t.TempDir(), "lifecycle.json")
+	for _, c := range cases {
+		os.WriteFile(filename, []byte(c.content), 0644)
+		_, err := loadLifecycle(filename)
+		if c.err == "" && err != nil {
+			t.Errorf("loadLifecycle %s failed: %s", c.name, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("loadLifecycle %s expect error %s, got: %v", c.name, c.err, err)
+		}
+	}
+}
+
+func Test_lifecycleSummary(t *testing.T) {
+	r := &s3.LifecycleRule{
+		Status: aws.String(s3.ExpirationStatusEnabled),
+		Filter: &s3.LifecycleRuleFilter{And: &s3.LifecycleRuleAndOperator{
+			Prefix:             aws.String("logs/"),
+			Tags:               []*s3.Tag{{Key: aws.String("env"), Value: aws.String("dev")}},
+			ObjectSizeLessThan: aws.Int64(1024),
+		}},
+		Expiration:  &s3.LifecycleExpiration{Days: aws.Int64(30)},
+		Transitions: []*s3.Transition{{Days: aws.Int64(7), StorageClass: aws.String(s3.TransitionStorageClassGlacier)}},
+		NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{
+			NoncurrentDays:          aws.Int64(7),
+			NewerNoncurrentVersions: aws.Int64(3),
+		},
+		AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(3)},
+	}
+	if got, want := lifecycleFilter(r), "prefix=logs/ tag=env=dev size<1024"; got != want {
+		t.Errorf("lifecycleFilter expect: %s, got: %s", want, got)
+	}
+	if got, want := lifecycleActions(r), "expire=30d transition=7d:GLACIER noncurrent-expire=7d(keep 3) abort-mpu=3d"; got != want {
+		t.Errorf("lifecycleActions expect: %s, got: %s", want, got)
+	}
+	if got := lifecycleFilter(&s3.LifecycleRule{Filter: &s3.LifecycleRuleFilter{}}); got != "all" {
+		t.Errorf("lifecycleFilter expect: all, got: %s", got)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// walkOptions filters the local files returned by walkLocalFiles
+type walkOptions struct {
+	include        []string // glob pattern(s) of files to include, all files if empty
+	exclude        []string // glob pattern(s) of files and directories to exclude
+	followSymlinks bool     // follow symlinks to files and directories, skip them if false
+}
+
+// match reports whether the slash separated relative path rel is selected.
+// A pattern matches if it matches the whole relative path or the file name.
+func (wo walkOptions) match(rel string) bool {
+	name := path.Base(rel)
+	globMatch := func(patterns []string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, rel); ok {
+				return true
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if globMatch(wo.exclude) {
+		return false
+	}
+	return len(wo.include) == 0 || globMatch(wo.include)
+}
+
+// excludeDir reports whether the directory of slash separated relative path rel and all files below it
+// are excluded. A pattern matches if it matches the whole relative path or the directory name,
+// a pattern ending with /* (e.g. vendor/*) matches the directory itself.
+func (wo walkOptions) excludeDir(rel string) bool {
+	name := path.Base(rel)
+	for _, p := range wo.exclude {
+		for _, p := range []string{p, strings.TrimSuffix(p, "/*")} {
+			if ok, _ := path.Match(p, rel); ok {
+				return true
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localFile is a regular file found by walkLocalFiles
+type localFile struct {
+	path    string // local path
+	rel     string // slash separated path relative to the walk root
+	size    int64
+	modTime time.Time
+}
+
+// walkLocalFiles returns all regular files under root(sorted by path) selected by wo.
+// If root is a file, it is returned with its file name as relative path.
+func walkLocalFiles(root string, wo walkOptions) ([]localFile, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []localFile{{path: root, rel: filepath.Base(root), size: info.Size(), modTime: info.ModTime()}}, nil
+	}
+
+	var files []localFile
+	visited := map[string]bool{} // real path of walked directories, break symlink loops
+	var walk func(dir, relDir string) error
+	walk = func(dir, relDir string) error {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			if visited[real] {
+				return nil
+			}
+			visited[real] = true
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			p := filepath.Join(dir, e.Name())
+			rel := path.Join(relDir, e.Name())
+			info, err := e.Info()
+			if err != nil {
+				return err
+			}
+			if info.Mode()&os.ModeSymlink != 0 {
+				if !wo.followSymlinks {
+					continue
+				}
+				if info, err = os.Stat(p); err != nil {
+					continue // dangling symlink
+				}
+			}
+			if info.IsDir() {
+				if wo.excludeDir(rel) {
+					continue
+				}
+				if err := walk(p, rel); err != nil {
+					return err
+				}
+				continue
+			}
+			if !info.Mode().IsRegular() || !wo.match(rel) {
+				continue
+			}
+			files = append(files, localFile{path: p, rel: rel, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	}
+
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// localPath joins the slash separated relative path rel(usually part of a Key) to dir,
+// it returns an error if the result is outside of dir(e.g. rel contains "..").
+func localPath(dir, rel string) (string, error) {
+	p := filepath.FromSlash(rel)
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("refuse to write %s outside of %s", rel, dir)
+	}
+	return filepath.Join(dir, p), nil
+}
+
+// checkNotExist returns an error if the local file filename exists
+func checkNotExist(filename string) error {
+	_, err := os.Lstat(filename)
+	if err == nil {
+		return fmt.Errorf("local file %s already exists(use --overwrite to replace it)", filename)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// createTemp creates a new temporary file in dir like os.CreateTemp, but with mode 0666(before umask)
+// like os.Create instead of 0600.
+func createTemp(dir, pattern string) (*os.File, error) {
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+		fd, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if errors.Is(err, os.ErrExist) && i < 10000 {
+			continue
+		}
+		return fd, err
+	}
+}
+
+// writeFileAtomic calls write with a temporary file in the directory of filename and
+// renames it to filename only if write succeeded, the temporary file is removed on any error.
+// An existing filename is replaced only if overwrite is true, see renameFile.
+func writeFileAtomic(filename string, overwrite bool, write func(fd *os.File) error) (err error) {
+	fd, err := createTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			fd.Close()
+			os.Remove(fd.Name())
+		}
+	}()
+	if err = write(fd); err != nil {
+		return err
+	}
+	if err = fd.Close(); err != nil {
+		return err
+	}
+	return renameFile(fd.Name(), filename, overwrite)
+}
+
+// renameFile renames oldpath to newpath, an existing newpath is replaced only if overwrite is true,
+// otherwise oldpath is linked to newpath, which fails if newpath is created in the meantime.
+func renameFile(oldpath, newpath string, overwrite bool) error {
+	if overwrite {
+		return os.Rename(oldpath, newpath)
+	}
+	err := os.Link(oldpath, newpath)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("local file %s already exists(use --overwrite to replace it)", newpath)
+	}
+	if err != nil {
+		// hard links are not supported by the file system, fall back to check and rename
+		if err = checkNotExist(newpath); err != nil {
+			return err
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	return os.Remove(oldpath)
+}
+
+// runWorkers calls fn(i) for every i in [0, n) with at most concurrency goroutines,
+// it returns after all calls finished.
+func runWorkers(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+	idx := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+}
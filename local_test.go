@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_walkOptionsMatch(t *testing.T) {
+	cases := []struct {
+		wo   walkOptions
+		rel  string
+		want bool
+	}{
+		{walkOptions{}, "a/b.txt", true},
+		{walkOptions{include: []string{"*.txt"}}, "a/b.txt", true},
+		{walkOptions{include: []string{"*.go"}}, "a/b.txt", false},
+		{walkOptions{exclude: []string{"*.txt"}}, "a/b.txt", false},
+		{walkOptions{exclude: []string{"a/*"}}, "a/b.txt", false},
+		{walkOptions{include: []string{"*.txt"}, exclude: []string{"b.*"}}, "a/b.txt", false},
+	}
+	for _, c := range cases {
+		if got := c.wo.match(c.rel); got != c.want {
+			t.Errorf("match(%+v, %s) expect: %v, got: %v", c.wo, c.rel, c.want, got)
+		}
+	}
+}
+
+func Test_walkLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"} {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed to create test dir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+	// symlink loop
+	if err := os.Symlink(dir, filepath.Join(dir, "sub", "loop")); err != nil {
+		t.Skipf("unable to create symlink: %v", err)
+	}
+
+	relOf := func(files []localFile) []string {
+		rels := make([]string, len(files))
+		for i, f := range files {
+			rels[i] = f.rel
+		}
+		return rels
+	}
+
+	files, err := walkLocalFiles(dir, walkOptions{})
+	if err != nil {
+		t.Fatalf("walkLocalFiles failed: %s", err)
+	}
+	if want := []string{"a.txt", "sub/b.txt", "sub/deep/c.txt"}; !reflect.DeepEqual(relOf(files), want) {
+		t.Errorf("walkLocalFiles expect: %v, got: %v", want, relOf(files))
+	}
+
+	for _, exclude := range []string{"sub/*", "sub", "deep"} {
+		files, err = walkLocalFiles(dir, walkOptions{exclude: []string{exclude}})
+		if err != nil {
+			t.Fatalf("walkLocalFiles exclude %s failed: %s", exclude, err)
+		}
+		want := []string{"a.txt"}
+		if exclude == "deep" {
+			want = []string{"a.txt", "sub/b.txt"}
+		}
+		if !reflect.DeepEqual(relOf(files), want) {
+			t.Errorf("walkLocalFiles exclude %s expect: %v, got: %v", exclude, want, relOf(files))
+		}
+	}
+
+	files, err = walkLocalFiles(dir, walkOptions{followSymlinks: true})
+	if err != nil {
+		t.Fatalf("walkLocalFiles follow symlinks failed: %s", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("walkLocalFiles follow symlinks expect 3 files, got: %v", relOf(files))
+	}
+
+	files, err = walkLocalFiles(filepath.Join(dir, "a.txt"), walkOptions{})
+	if err != nil {
+		t.Fatalf("walkLocalFiles file failed: %s", err)
+	}
+	if want := []string{"a.txt"}; !reflect.DeepEqual(relOf(files), want) {
+		t.Errorf("walkLocalFiles file expect: %v, got: %v", want, relOf(files))
+	}
+}
+
+func Test_localPath(t *testing.T) {
+	cases := map[string]bool{
+		"a":             true,
+		"a/b/c":         true,
+		"a/../b":        true,
+		"":              false,
+		"..":            false,
+		"../a":          false,
+		"a/../../b":     false,
+		"/etc/passwd":   false,
+		"a/b/../../../": false,
+	}
+	for rel, ok := range cases {
+		p, err := localPath("dir", rel)
+		if (err == nil) != ok {
+			t.Errorf("localPath(%s) expect ok: %v, got: %s, %v", rel, ok, p, err)
+		}
+	}
+}
+
+func Test_writeFileAtomic(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file")
+	write := func(data string) func(fd *os.File) error {
+		return func(fd *os.File) error {
+			_, err := fd.WriteString(data)
+			return err
+		}
+	}
+	if err := writeFileAtomic(filename, false, write("v1")); err != nil {
+		t.Fatalf("writeFileAtomic failed: %s", err)
+	}
+	if err := writeFileAtomic(filename, false, write("v2")); err == nil {
+		t.Errorf("writeFileAtomic expect error of existing file")
+	}
+	// same mode as os.Create(umask applied)
+	created := filepath.Join(filepath.Dir(filename), "created")
+	if fd, err := os.Create(created); err == nil {
+		fd.Close()
+	}
+	if info, err := os.Stat(filename); err != nil {
+		t.Errorf("writeFileAtomic stat failed: %s", err)
+	} else if want, _ := os.Stat(created); want != nil && info.Mode() != want.Mode() {
+		t.Errorf("writeFileAtomic expect mode %s, got: %s", want.Mode(), info.Mode())
+	}
+	os.Remove(created)
+	// target created while writing
+	race := filepath.Join(filepath.Dir(filename), "race")
+	err := writeFileAtomic(race, false, func(fd *os.File) error {
+		return os.WriteFile(race, []byte("other"), 0644)
+	})
+	if data, _ := os.ReadFile(race); err == nil || string(data) != "other" {
+		t.Errorf("writeFileAtomic expect error of file created meanwhile, got: %v, content: %s", err, data)
+	}
+	os.Remove(race)
+	errWrite := errors.New("interrupted")
+	err = writeFileAtomic(filename, true, func(fd *os.File) error {
+		fd.WriteString("partial")
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Errorf("writeFileAtomic expect error %v, got: %v", errWrite, err)
+	}
+	if err := writeFileAtomic(filename, true, write("v3")); err != nil {
+		t.Errorf("writeFileAtomic overwrite failed: %s", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil || string(data) != "v3" {
+		t.Errorf("writeFileAtomic expect content v3, got: %s, %v", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("writeFileAtomic left temporary file(s): %v", entries)
+	}
+}
+
+func Test_runWorkers(t *testing.T) {
+	var sum, running, maxRunning int64
+	runWorkers(100, 4, func(i int) {
+		n := atomic.AddInt64(&running, 1)
+		for {
+			m := atomic.LoadInt64(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+				break
+			}
+		}
+		atomic.AddInt64(&sum, int64(i))
+		atomic.AddInt64(&running, -1)
+	})
+	if sum != 4950 {
+		t.Errorf("runWorkers expect sum: 4950, got: %d", sum)
+	}
+	if maxRunning > 4 {
+		t.Errorf("runWorkers expect at most 4 workers, got: %d", maxRunning)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// parseLockMode returns the Object Lock retention mode(GOVERNANCE or COMPLIANCE) of mode in any case
+func parseLockMode(mode string) (string, error) {
+	mode = strings.ToUpper(mode)
+	if !slices.Contains(s3.ObjectLockRetentionMode_Values(), mode) {
+		return "", fmt.Errorf("invalid lock mode %s(%s)", mode, strings.Join(s3.ObjectLockRetentionMode_Values(), " or "))
+	}
+	return mode, nil
+}
+
+// parseRetainUntil parses a future retain-until date of format RFC3339(2006-01-02T15:04:05Z) or 2006-01-02(UTC)
+func parseRetainUntil(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, s); err != nil {
+			return time.Time{}, fmt.Errorf("invalid retain-until %s(format 2006-01-02T15:04:05Z or 2006-01-02)", s)
+		}
+	}
+	if !t.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("retain-until %s is not in the future", s)
+	}
+	return t, nil
+}
+
+// parseLegalHold returns the legal hold status(ON or OFF) of status in any case
+func parseLegalHold(status string) (string, error) {
+	status = strings.ToUpper(status)
+	if !slices.Contains(s3.ObjectLockLegalHoldStatus_Values(), status) {
+		return "", fmt.Errorf("invalid legal hold %s(%s)", status, strings.Join(s3.ObjectLockLegalHoldStatus_Values(), " or "))
+	}
+	return status, nil
+}
+
+// setLockOptions sets the Object Lock retention of uploads to mode until, both or neither must be set
+func (sc *S3Cli) setLockOptions(mode, until string) (err error) {
+	if mode == "" && until == "" {
+		return nil
+	}
+	if mode == "" || until == "" {
+		return errors.New("lock-mode and retain-until must be specified together")
+	}
+	if sc.lockMode, err = parseLockMode(mode); err != nil {
+		return err
+	}
+	sc.lockUntil, err = parseRetainUntil(until)
+	return err
+}
+
+// checkLockIntegrity returns an error if uploads set Object Lock retention or legal hold without
+// Content-MD5(disabled by --no-md5-validate) or a checksum, S3 rejects such a PutObject or UploadPart
+func (sc *S3Cli) checkLockIntegrity(md5Disabled bool) error {
+	if (sc.lockMode != "" || sc.legalHold) && md5Disabled && sc.checksum == "" {
+		return errors.New("lock-mode and legal-hold require Content-MD5 or a checksum, use --checksum-algorithm with --no-md5-validate")
+	}
+	return nil
+}
+
+// setObjectLock sets the Object Lock retention and legal hold of uploads to v(e.g. *s3.PutObjectInput)
+func (sc *S3Cli) setObjectLock(v interface{}) {
+	if sc.lockMode != "" {
+		awsutil.SetValueAtPath(v, "ObjectLockMode", aws.String(sc.lockMode))
+		awsutil.SetValueAtPath(v, "ObjectLockRetainUntilDate", aws.Time(sc.lockUntil))
+	}
+	if sc.legalHold {
+		awsutil.SetValueAtPath(v, "ObjectLockLegalHoldStatus", aws.String(s3.ObjectLockLegalHoldStatusOn))
+	}
+}
+
+// getObjectRetention prints the Object Lock retention of Object bucket/key
+func (sc *S3Cli) getObjectRetention(ctx context.Context, bucket, key, version string) error {
+	input := &s3.GetObjectRetentionInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.GetObjectRetentionRequest(input)
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("get object retention %s failed: %w", key, err)
+	}
+	retention := resp.Retention
+	if retention == nil {
+		retention = &s3.ObjectLockRetention{}
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			fmt.Println(resp)
+			return nil
+		}
+		fmt.Printf("%s", jo)
+	} else if sc.verboseOutput() {
+		fmt.Println(resp)
+	} else if sc.lineOutput() {
+		fmt.Printf("%s\t%s\n", aws.StringValue(retention.Mode), aws.TimeValue(retention.RetainUntilDate).Format(time.RFC3339))
+	} else {
+		fmt.Printf("Mode            : %s\n", aws.StringValue(retention.Mode))
+		fmt.Printf("RetainUntilDate : %s\n", aws.TimeValue(retention.RetainUntilDate).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// putObjectRetention sets the Object Lock retention of Object bucket/key to mode until,
+// bypass is required to shorten or remove a GOVERNANCE retention.
+func (sc *S3Cli) putObjectRetention(ctx context.Context, bucket, key, version, mode string, until time.Time, bypass bool) error {
+	input := &s3.PutObjectRetentionInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Retention: &s3.ObjectLockRetention{
+			Mode:            aws.String(mode),
+			RetainUntilDate: aws.Time(until),
+		},
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	if bypass {
+		input.BypassGovernanceRetention = aws.Bool(true)
+	}
+	req, resp := sc.Client.PutObjectRetentionRequest(input)
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put object retention %s failed: %w", key, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// getObjectLegalHold prints the legal hold status of Object bucket/key
+func (sc *S3Cli) getObjectLegalHold(ctx context.Context, bucket, key, version string) error {
+	input := &s3.GetObjectLegalHoldInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.GetObjectLegalHoldRequest(input)
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("get object legal hold %s failed: %w", key, err)
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			fmt.Println(resp)
+			return nil
+		}
+		fmt.Printf("%s", jo)
+	} else if sc.verboseOutput() {
+		fmt.Println(resp)
+	} else if resp.LegalHold != nil {
+		fmt.Println(aws.StringValue(resp.LegalHold.Status))
+	}
+	return nil
+}
+
+// putObjectLegalHold sets the legal hold status(ON or OFF) of Object bucket/key
+func (sc *S3Cli) putObjectLegalHold(ctx context.Context, bucket, key, version, status string) error {
+	input := &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		LegalHold: &s3.ObjectLockLegalHold{Status: aws.String(status)},
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.PutObjectLegalHoldRequest(input)
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put object legal hold %s failed: %w", key, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
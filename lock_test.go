@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/johannesboyne/gofakes3"
+)
+
+func Test_parseRetainUntil(t *testing.T) {
+	future := time.Now().AddDate(1, 0, 0).UTC()
+	for _, v := range []string{future.Format(time.RFC3339), future.Format(time.DateOnly)} {
+		if _, err := parseRetainUntil(v); err != nil {
+			t.Errorf("parseRetainUntil(%s) failed: %s", v, err)
+		}
+	}
+	for _, v := range []string{"2000-01-01", "2000-01-01T00:00:00Z", "tomorrow"} {
+		if _, err := parseRetainUntil(v); err == nil {
+			t.Errorf("parseRetainUntil(%s) expect error", v)
+		}
+	}
+}
+
+func Test_setLockOptions(t *testing.T) {
+	until := time.Now().AddDate(1, 0, 0).UTC().Format(time.DateOnly)
+	cases := []struct {
+		mode  string
+		until string
+		ok    bool
+	}{
+		{"", "", true},
+		{"governance", until, true},
+		{"COMPLIANCE", until, true},
+		{"COMPLIANCE", "", false},
+		{"", until, false},
+		{"LEGAL", until, false},
+	}
+	for _, c := range cases {
+		sc := s3cliTest
+		err := sc.setLockOptions(c.mode, c.until)
+		if c.ok != (err == nil) {
+			t.Errorf("setLockOptions(%s, %s) expect ok %v, got: %v", c.mode, c.until, c.ok, err)
+		}
+		if c.ok && sc.lockMode != strings.ToUpper(c.mode) {
+			t.Errorf("setLockOptions(%s, %s) expect mode %s, got: %s", c.mode, c.until, strings.ToUpper(c.mode), sc.lockMode)
+		}
+	}
+
+	sc := s3cliTest
+	sc.setLockOptions("GOVERNANCE", until)
+	sc.legalHold = true
+	input := &s3manager.UploadInput{}
+	sc.setObjectLock(input)
+	if aws.StringValue(input.ObjectLockMode) != s3.ObjectLockModeGovernance || !aws.TimeValue(input.ObjectLockRetainUntilDate).Equal(sc.lockUntil) ||
+		aws.StringValue(input.ObjectLockLegalHoldStatus) != s3.ObjectLockLegalHoldStatusOn {
+		t.Errorf("setObjectLock unexpected UploadInput: %v", input)
+	}
+}
+
+func Test_checkLockIntegrity(t *testing.T) {
+	cases := []struct {
+		mode        string
+		legalHold   bool
+		checksum    string
+		md5Disabled bool
+		ok          bool
+	}{
+		{"", false, "", true, true},
+		{"GOVERNANCE", false, "", false, true},
+		{"GOVERNANCE", false, "", true, false},
+		{"", true, "", true, false},
+		{"GOVERNANCE", true, "CRC32", true, true},
+	}
+	for _, c := range cases {
+		sc := s3cliTest
+		sc.lockMode, sc.legalHold, sc.checksum = c.mode, c.legalHold, c.checksum
+		if err := sc.checkLockIntegrity(c.md5Disabled); c.ok != (err == nil) {
+			t.Errorf("checkLockIntegrity(%+v) expect ok %v, got: %v", c, c.ok, err)
+		}
+	}
+}
+
+func Test_objectLock(t *testing.T) {
+	// the fake S3 does not support Object Lock, record the requests and respond the last retention and legal hold
+	mu := sync.Mutex{}
+	lockRequests := map[string]string{}
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		for _, sub := range []string{"retention", "legal-hold"} {
+			if _, ok := query[sub]; !ok {
+				continue
+			}
+			if r.Method == http.MethodPut {
+				body, _ := io.ReadAll(r.Body)
+				lockRequests[sub] = string(body)
+				return
+			}
+			io.WriteString(w, lockRequests[sub])
+			return
+		}
+		if r.Method == http.MethodPut && r.Header.Get("x-amz-object-lock-mode") != "" {
+			lockRequests["upload"] = r.Header.Get("Content-Md5")
+		}
+		if r.Method == http.MethodPut && query.Get("uploadId") != "" {
+			lockRequests["part"+query.Get("partNumber")] = r.Header.Get("Content-Md5")
+		}
+		faker.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+	key := "objectLock"
+
+	until := time.Now().AddDate(1, 0, 0).UTC().Truncate(time.Second)
+	if err := sc.setLockOptions("COMPLIANCE", until.Format(time.RFC3339)); err != nil {
+		t.Fatalf("setLockOptions failed: %s", err)
+	}
+	if _, err := sc.sendPutObject(context.Background(), testBucketName, key, "", nil, false, bytes.NewReader(testObjectContent)); err != nil {
+		t.Fatalf("sendPutObject with lock failed: %s", err)
+	}
+	if lockRequests["upload"] == "" {
+		t.Errorf("sendPutObject with lock expect header Content-MD5")
+	}
+
+	// stdin is buffered, so the SDK sends Content-MD5 of the PUT and the parts
+	lockRequests["upload"] = ""
+	stdin := io.MultiReader(bytes.NewReader(testObjectContent))
+	mo := mpuOptions{threshold: 1 << 20, partSize: s3manager.MinUploadPartSize, concurrency: 1}
+	if err := sc.putObjectStream(context.Background(), testBucketName, key, "", nil, mo, stdin); err != nil {
+		t.Fatalf("putObjectStream with lock failed: %s", err)
+	}
+	if lockRequests["upload"] == "" {
+		t.Errorf("putObjectStream with lock expect header Content-MD5")
+	}
+	stdin = io.MultiReader(bytes.NewReader(make([]byte, s3manager.MinUploadPartSize+1)))
+	if err := sc.putObjectStream(context.Background(), testBucketName, key, "", nil, mo, stdin); err != nil {
+		t.Fatalf("putObjectStream mpu with lock failed: %s", err)
+	}
+	if lockRequests["part1"] == "" || lockRequests["part2"] == "" {
+		t.Errorf("putObjectStream mpu with lock expect header Content-MD5 of parts, got: %q, %q", lockRequests["part1"], lockRequests["part2"])
+	}
+
+	if err := sc.putObjectRetention(context.Background(), testBucketName, key, "", s3.ObjectLockModeGovernance, until, true); err != nil {
+		t.Fatalf("putObjectRetention failed: %s", err)
+	}
+	if !strings.Contains(lockRequests["retention"], "<Mode>GOVERNANCE</Mode>") {
+		t.Errorf("putObjectRetention unexpected request: %s", lockRequests["retention"])
+	}
+	lockRequests["retention"] = `<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>` + until.Format(time.RFC3339) + `</RetainUntilDate></Retention>`
+	if err := sc.getObjectRetention(context.Background(), testBucketName, key, ""); err != nil {
+		t.Errorf("getObjectRetention failed: %s", err)
+	}
+
+	if err := sc.putObjectLegalHold(context.Background(), testBucketName, key, "", s3.ObjectLockLegalHoldStatusOn); err != nil {
+		t.Fatalf("putObjectLegalHold failed: %s", err)
+	}
+	if !strings.Contains(lockRequests["legal-hold"], "<Status>ON</Status>") {
+		t.Errorf("putObjectLegalHold unexpected request: %s", lockRequests["legal-hold"])
+	}
+	if err := sc.getObjectLegalHold(context.Background(), testBucketName, key, ""); err != nil {
+		t.Errorf("getObjectLegalHold failed: %s", err)
+	}
+}
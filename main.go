@@ -29,6 +29,12 @@ import (
 const (
 	defaultDialTimeout           = 10
 	defaultResponseHeaderTimeout = 20
+	// upload files(or stdin) larger than it with mpu in parts of defaultPartSize
+	defaultMPUThreshold = 64 << 20
+	defaultPartSize     = 16 << 20
+	// copy Objects larger than it with UploadPartCopy in parts of defaultCopyPartSize
+	defaultCopyMPUThreshold = 5 << 30
+	defaultCopyPartSize     = 512 << 20
 )
 
 var (
@@ -50,19 +56,52 @@ var (
 	cleanURI                  = false
 	noProxy                   = false
 	insecureSkipVerify        = true // Skip TLS certificate verification (use with caution)
+	limitRate                 string
+	// shared by all clients, nil if limitRate is not set
+	bandwidthLimiter *rateLimiter
 )
 
-func newS3Client(sc *S3Cli) (*s3.S3, error) {
+// offlineCommand reports whether cmd sends no request, so it runs without a S3 client(and endpoint)
+func offlineCommand(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "policy":
+		return cmd.Flags().Changed("dry-run")
+	case "policy-check":
+		return cmd.Flags().Changed("file")
+	}
+	return false
+}
+
+// resolveEndpoint sets sc.endpoint from --endpoint, S3_ENDPOINT or endpoint_url of the profile,
+// prefixed with http:// if it has no scheme
+func resolveEndpoint(sc *S3Cli) error {
 	if sc.endpoint == "" {
 		sc.endpoint = os.Getenv(endpointEnvVar)
 	}
 	if sc.endpoint == "" {
-		return nil, errors.New("unknown endpoint")
+		profile := sc.profile
+		if profile == "" {
+			profile = os.Getenv("AWS_PROFILE")
+		}
+		if profile == "" {
+			profile = "default"
+		}
+		sc.endpoint = sharedConfigEndpoint(profile)
+	}
+	if sc.endpoint == "" {
+		return errors.New("unknown endpoint")
 	}
 
 	if !strings.HasPrefix(sc.endpoint, "http://") && !strings.HasPrefix(sc.endpoint, "https://") {
 		sc.endpoint = "http://" + sc.endpoint
 	}
+	return nil
+}
+
+func newS3Client(sc *S3Cli) (*s3.S3, error) {
+	if err := resolveEndpoint(sc); err != nil {
+		return nil, err
+	}
 
 	if sc.accessKey == "" {
 		sc.accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
@@ -103,6 +142,7 @@ func newS3Client(sc *S3Cli) (*s3.S3, error) {
 		tp.Proxy = http.ProxyFromEnvironment
 	}
 
+	httpClient := &http.Client{Transport: tp}
 	sessionOptions := session.Options{
 		SharedConfigState: session.SharedConfigEnable,
 		Config: aws.Config{
@@ -110,9 +150,7 @@ func newS3Client(sc *S3Cli) (*s3.S3, error) {
 			S3ForcePathStyle:               aws.Bool(!virtualHostStyle),
 			S3DisableContentMD5Validation:  aws.Bool(disableContentMd5Validate),
 			DisableRestProtocolURICleaning: aws.Bool(!cleanURI),
-			HTTPClient: &http.Client{
-				Transport: tp,
-			},
+			HTTPClient:                     httpClient,
 			EndpointResolver: endpoints.ResolverFunc(
 				func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
 					if service == "s3" {
@@ -139,6 +177,8 @@ func newS3Client(sc *S3Cli) (*s3.S3, error) {
 		sessionOptions.Config.Credentials = credentials.NewStaticCredentials(sc.accessKey, sc.secretKey, sc.tokenKey)
 	}
 	ses := session.Must(session.NewSessionWithOptions(sessionOptions))
+	// wrapped after the session is created, which loads the custom CA bundle(AWS_CA_BUNDLE) into tp
+	httpClient.Transport = &transferTransport{RoundTripper: tp, l: bandwidthLimiter}
 
 	if sc.debug > 0 {
 		ses.Config.LogLevel = aws.LogLevel(aws.LogDebug + aws.LogLevelType(sc.debug-1))
@@ -164,9 +204,86 @@ func newS3Client(sc *S3Cli) (*s3.S3, error) {
 	return svc, nil
 }
 
+// sharedConfigFile is a shared config or credentials file and the sections of a profile in it
+type sharedConfigFile struct {
+	name     string
+	sections []string
+}
+
+// sharedConfigFiles returns the shared config file(AWS_CONFIG_FILE, default ~/.aws/config) and
+// credentials file(AWS_SHARED_CREDENTIALS_FILE, default ~/.aws/credentials) with the sections of profile
+func sharedConfigFiles(profile string) []sharedConfigFile {
+	home, _ := os.UserHomeDir()
+	files := []sharedConfigFile{
+		{filepath.Join(home, ".aws", "config"), []string{"profile " + profile, profile}},
+		{filepath.Join(home, ".aws", "credentials"), []string{profile}},
+	}
+	for i, env := range []string{"AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE"} {
+		if name := os.Getenv(env); name != "" {
+			files[i].name = name
+		}
+	}
+	return files
+}
+
+// sharedConfigEndpoint returns endpoint_url of profile in the shared config or credentials file,
+// it is not read by aws-sdk-go v1.
+func sharedConfigEndpoint(profile string) string {
+	for _, f := range sharedConfigFiles(profile) {
+		if endpoint, _ := iniValue(f.name, f.sections, "endpoint_url"); endpoint != "" {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+// sharedConfigProfile reports whether profile is in the shared config or credentials file
+func sharedConfigProfile(profile string) bool {
+	for _, f := range sharedConfigFiles(profile) {
+		if _, found := iniValue(f.name, f.sections, ""); found {
+			return true
+		}
+	}
+	return false
+}
+
+// iniValue returns the value of key in any of sections of ini file name, or empty string if not found.
+// found reports whether any of sections is in the file.
+func iniValue(name string, sections []string, key string) (value string, found bool) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", false
+	}
+	var inSection bool
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			inSection = false
+			for _, s := range sections {
+				if section == s {
+					inSection = true
+					found = true
+				}
+			}
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); inSection && ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", found
+}
+
 func main() {
 	sc := S3Cli{}
 	objectMetadata := []string{}
+	objectTags := []string{}
+	objectLockMode := ""
+	objectRetainUntil := ""
 	objectContentType := ""
 	objectContentData := ""
 	ctx, cancelCtx := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
@@ -178,6 +295,7 @@ func main() {
 	github.com/shvc/s3cli
 EnvVar:
 	S3_ENDPOINT=http://host:port (only read if flag --endpoint is not set)
+	endpoint_url of the profile in ~/.aws/config (only read if --endpoint and S3_ENDPOINT are not set)
 	AWS_PROFILE=profile          (only read if flag --profile is not set)
 	AWS_ACCESS_KEY_ID=ak         (only read if flag --ak and --profile not set)
 	AWS_ACCESS_KEY=ak            (only read if AWS_ACCESS_KEY_ID is not set)
@@ -187,7 +305,17 @@ EnvVar:
 	`,
 		Version: version,
 		Hidden:  true,
-		PersistentPreRunE: func(*cobra.Command, []string) error {
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if limitRate != "" {
+				rate, err := parseRate(limitRate)
+				if err != nil {
+					return err
+				}
+				bandwidthLimiter = newRateLimiter(rate)
+			}
+			if offlineCommand(cmd) {
+				return nil
+			}
 			var err error
 			sc.Client, err = newS3Client(&sc)
 			return err
@@ -209,6 +337,8 @@ EnvVar:
 	rootCmd.PersistentFlags().BoolVarP(&noProxy, "noproxy", "", false, "disable http proxy")
 	rootCmd.PersistentFlags().BoolVarP(&disableContentMd5Validate, "no-md5-validate", "", false, "disable content md5 validate(header Content-Md5)")
 	rootCmd.PersistentFlags().BoolVarP(&cleanURI, "clean-uri", "", false, "clean the URL path when making S3 rest requests")
+	rootCmd.PersistentFlags().BoolVarP(&sc.progress, "progress", "", false, "show progress(bytes, rate and ETA) of upload, download and mpu")
+	rootCmd.PersistentFlags().StringVarP(&limitRate, "limit-rate", "", "", "limit the total bandwidth of all transfers(e.g. 500K, 50MiB/s)")
 	rootCmd.PersistentFlags().IntVarP(&dialTimeout, "dial-timeout", "", defaultDialTimeout, "http dial timeout in seconds")
 	rootCmd.PersistentFlags().IntVarP(&responseHeaderTimeout, "response-header-timeout", "", defaultResponseHeaderTimeout, "http response header timeout in seconds")
 	rootCmd.PersistentFlags().StringArrayVarP(&sc.header, "header", "H", nil, "Pass custom header(s) to server(format Key:Value)")
@@ -312,23 +442,118 @@ EnvVar:
 	rootCmd.AddCommand(bucketEncryptionDeleteCmd)
 
 	bucketPolicyCmd := &cobra.Command{
-		Use:   "policy <bucket> [policy]",
+		Use:   "policy <bucket> [policy|file|-]",
 		Short: "get/set Bucket Policy",
 		Long: `get/set Bucket Policy usage:
 * get Bucket policy
 	s3cli policy bucket-name
 * set Bucket policy(json http://awspolicygen.s3.amazonaws.com/policygen.html)
-	s3cli policy bucket-name '{json}'`,
+	s3cli policy bucket-name '{json}'
+* set Bucket policy from a file or stdin
+	s3cli policy bucket-name policy.json
+	cat policy.json | s3cli policy bucket-name -
+* validate and pretty-print a policy without setting it
+	s3cli policy bucket-name policy.json --dry-run
+* set Bucket policy from a built-in template
+	s3cli policy bucket-name --template public-read-prefix --param public/
+	s3cli policy bucket-name --template read-only-for-principal --param arn:aws:iam::123456789012:user/name
+	s3cli policy bucket-name --template deny-insecure-transport --dry-run
+
+* policy is validated(Version, Statement, Effect, Principal, Action and Resource) before it is set, unless --no-validate
+	s3cli policy bucket-name policy.json --no-validate
+* all built-in templates:
+` + policyTemplateUsage(),
 		Args: cobra.RangeArgs(1, 2),
-		RunE: func(_ *cobra.Command, args []string) error {
-			if len(args) == 1 {
-				return sc.errorHandler(sc.bucketPolicyGet(ctx, args[0]))
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket := args[0]
+			template := cmd.Flag("template").Value.String()
+			if len(args) == 1 && template == "" {
+				return sc.errorHandler(sc.bucketPolicyGet(ctx, bucket))
+			}
+			var policy []byte
+			var err error
+			if template != "" {
+				if len(args) > 1 {
+					return errors.New("--template does not take a policy")
+				}
+				policy, err = renderPolicy(template, bucket, cmd.Flag("param").Value.String())
+			} else {
+				policy, err = readPolicy(args[1], os.Stdin)
 			}
-			return sc.errorHandler(sc.bucketPolicySet(ctx, args[0], args[1]))
+			if err != nil {
+				return sc.errorHandler(err)
+			}
+			validate := !cmd.Flag("no-validate").Changed
+			if cmd.Flag("dry-run").Changed {
+				if validate {
+					if _, err := checkPolicy(bucket, policy); err != nil {
+						return sc.errorHandler(err)
+					}
+				}
+				fmt.Println(formatPolicy(policy, sc.lineOutput()))
+				return nil
+			}
+			return sc.errorHandler(sc.bucketPolicySet(ctx, bucket, string(policy), validate))
 		},
 	}
+	bucketPolicyCmd.Flags().String("template", "", "set the built-in policy template")
+	bucketPolicyCmd.Flags().String("param", "", "parameter of --template(e.g. prefix of public-read-prefix)")
+	bucketPolicyCmd.Flags().Bool("dry-run", false, "validate and print the policy, do not set it")
+	bucketPolicyCmd.Flags().Bool("no-validate", false, "do not validate the policy(e.g. it is valid for the S3 service but not for s3cli)")
 	rootCmd.AddCommand(bucketPolicyCmd)
 
+	var policyCheckConditions []string
+	policyCheckCmd := &cobra.Command{
+		Use:   "policy-check <bucket[/key]>",
+		Short: "evaluate Bucket Policy offline",
+		Long: `evaluate whether Bucket Policy allows principal to do action on Bucket or Object usage:
+* can anyone(anonymous) read Object key
+	s3cli policy-check bucket-name/key --action s3:GetObject
+* can a user delete Object key
+	s3cli policy-check bucket-name/key --principal arn:aws:iam::123456789012:user/name --action s3:DeleteObject
+* can a user list Bucket from 10.1.2.3, evaluate policy.json(or - for stdin) instead of the Bucket Policy
+	s3cli policy-check bucket-name --principal arn:aws:iam::123456789012:user/name --action s3:ListBucket --condition aws:SourceIp=10.1.2.3 --file policy.json
+* show the match result of all statements
+	s3cli policy-check bucket-name/key --action s3:GetObject -o verbose
+
+* decision is allow, deny(by a Deny statement) or implicit-deny(no statement allows the request)
+* only Bucket Policy is evaluated(not IAM policies, ACLs or Bucket owner)
+* aws:SecureTransport is true if the endpoint is https unless it is set with --condition(unset if there is no endpoint)`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			action := cmd.Flag("action").Value.String()
+			if !strings.Contains(action, ":") {
+				action = "s3:" + action
+			}
+			req := policyRequest{
+				Principal: cmd.Flag("principal").Value.String(),
+				Action:    action,
+				Resource:  policyResource(bucket, key),
+				Context:   map[string][]string{},
+			}
+			for _, c := range policyCheckConditions {
+				k, v, ok := strings.Cut(c, "=")
+				if !ok || k == "" {
+					return fmt.Errorf("invalid condition %s(format Key=Value)", c)
+				}
+				k = strings.ToLower(k) // condition keys are case insensitive
+				req.Context[k] = append(req.Context[k], v)
+			}
+			// resolved without a client if the policy is in --file, unset if there is no endpoint
+			if _, ok := req.Context["aws:securetransport"]; !ok && resolveEndpoint(&sc) == nil {
+				req.Context["aws:securetransport"] = []string{strconv.FormatBool(strings.HasPrefix(sc.endpoint, "https://"))}
+			}
+			return sc.errorHandler(sc.policyCheck(ctx, bucket, cmd.Flag("file").Value.String(), req))
+		},
+	}
+	policyCheckCmd.Flags().String("principal", "*", "principal(e.g. arn:aws:iam::123456789012:user/name), * is anonymous")
+	policyCheckCmd.Flags().String("action", "", "action(e.g. s3:GetObject)")
+	policyCheckCmd.Flags().StringArrayVar(&policyCheckConditions, "condition", nil, "condition key of the request(format Key=Value, e.g. aws:SourceIp=10.1.2.3)")
+	policyCheckCmd.Flags().String("file", "", "evaluate policy in file(or - for stdin) instead of the Bucket Policy")
+	policyCheckCmd.MarkFlagRequired("action")
+	rootCmd.AddCommand(policyCheckCmd)
+
 	bucketVersionCmd := &cobra.Command{
 		Use:     "version <bucket/key> [arg]",
 		Aliases: []string{"v"},
@@ -397,7 +622,82 @@ EnvVar:
 	bucketCorsCmd.Flags().BoolVar(&corsDelete, "delete", false, "delete bucket cors")
 	rootCmd.AddCommand(bucketCorsCmd)
 
+	var bucketTagDelete bool
+	bucketTagCmd := &cobra.Command{
+		Use:   "bucket-tag <bucket> [Key=Value ...|file]",
+		Short: "bucket tagging",
+		Long: `get/delete/set bucket tagging usage:
+* get Bucket tags
+	s3cli bucket-tag bucket-name
+* delete Bucket tags
+	s3cli bucket-tag bucket-name --delete
+* set Bucket tags
+	s3cli bucket-tag bucket-name cost-center=1024 team=storage
+* set Bucket tags from a JSON file({"TagSet": [{"Key": "k", "Value": "v"}]}, the same as -o json)
+	s3cli bucket-tag bucket-name tags.json
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			bucket, _ := sc.splitKeyValue(args[0], "/")
+			if bucketTagDelete && len(args) > 1 {
+				return errors.New("--delete does not take tags")
+			}
+			if len(args) == 1 {
+				if bucketTagDelete {
+					return sc.errorHandler(sc.deleteBucketTagging(ctx, bucket))
+				}
+				return sc.errorHandler(sc.getBucketTagging(ctx, bucket))
+			}
+			var tags []*s3.Tag
+			var err error
+			if len(args) == 2 && !strings.Contains(args[1], "=") {
+				tags, err = loadTags(args[1])
+			} else {
+				tags, err = parseTags(args[1:])
+			}
+			if err != nil {
+				return err
+			}
+			return sc.errorHandler(sc.putBucketTagging(ctx, bucket, tags))
+		},
+	}
+	bucketTagCmd.Flags().BoolVar(&bucketTagDelete, "delete", false, "delete bucket tagging")
+	rootCmd.AddCommand(bucketTagCmd)
+
+	var lifecycleDelete bool
+	bucketLifecycleCmd := &cobra.Command{
+		Use:   "lifecycle <bucket> [file]",
+		Short: "bucket lifecycle",
+		Long: `get/delete/set bucket lifecycle usage:
+* get Bucket lifecycle rules
+	s3cli lifecycle bucket-name
+* delete Bucket lifecycle rules
+	s3cli lifecycle bucket-name --delete
+* set Bucket lifecycle rules(BucketLifecycleConfiguration JSON, the same as -o json)
+	s3cli lifecycle bucket-name lifecycle.json
+* lifecycle.json to expire Objects(logs/) after 30 days, noncurrent versions after 7 days and incomplete MPUs after 3 days
+	{"Rules": [{"ID": "logs", "Status": "Enabled", "Filter": {"Prefix": "logs/"},
+	  "Expiration": {"Days": 30}, "NoncurrentVersionExpiration": {"NoncurrentDays": 7},
+	  "AbortIncompleteMultipartUpload": {"DaysAfterInitiation": 3}}]}
+`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			bucket, _ := sc.splitKeyValue(args[0], "/")
+			if len(args) == 1 {
+				if lifecycleDelete {
+					return sc.errorHandler(sc.deleteBucketLifecycle(ctx, bucket))
+				}
+				return sc.errorHandler(sc.getBucketLifecycle(ctx, bucket))
+			}
+			return sc.errorHandler(sc.putBucketLifecycle(ctx, bucket, args[1]))
+		},
+	}
+	bucketLifecycleCmd.Flags().BoolVar(&lifecycleDelete, "delete", false, "delete bucket lifecycle")
+	rootCmd.AddCommand(bucketLifecycleCmd)
+
 	// object upload(put)
+	var uploadWalk walkOptions
+	var uploadConcurrency = s3manager.DefaultUploadConcurrency
 	uploadObjectCmd := &cobra.Command{
 		Use:     "upload <bucket[/key]> [file ...]",
 		Aliases: []string{"put"},
@@ -415,6 +715,20 @@ EnvVar:
 	s3cli upload bucket-name/dir2/ *.txt
 * upload a Object with given contents
 	s3cli upload bucket-name/key --data text-content
+* upload a directory recursively to Bucket with specified prefix(dir/)
+	s3cli upload bucket-name/dir/ /path/to/dir --recursive
+* upload *.go files in a directory recursively, skip the vendor directory
+	s3cli upload bucket-name/dir/ /path/to/dir -r --include '*.go' --exclude 'vendor/*'
+* upload from stdin(mpu if it is larger than --mpu-threshold)
+	pg_dump db | s3cli upload bucket-name/db.sql -
+* upload a large file with mpu if it is larger than 1GB, in parts of 64MB
+	s3cli upload bucket-name/key /path/to/file --mpu-threshold 1024 --part-size 64
+* upload a file with CRC32C checksum(validated and stored by server)
+	s3cli upload bucket-name/key /path/to/file --checksum-algorithm CRC32C
+* upload a file with tags
+	s3cli upload bucket-name/key /path/to/file --tag env=prod --tag team=storage
+* upload a file locked(Object Lock) in COMPLIANCE mode until 2030-01-01 and with legal hold
+	s3cli upload bucket-name/key /path/to/file --lock-mode COMPLIANCE --retain-until 2030-01-01 --legal-hold
 * presign(V4) a PUT Object URL
 	s3cli upload bucket-name/key --presign`,
 		Args: cobra.MinimumNArgs(1),
@@ -432,6 +746,55 @@ EnvVar:
 					metadata[k] = &v
 				}
 			}
+			partSize, err := strconv.ParseInt(cmd.Flag("part-size").Value.String(), 10, 64)
+			if err != nil || partSize < 1 {
+				return fmt.Errorf("invalid part-size %s", cmd.Flag("part-size").Value.String())
+			}
+			threshold, err := strconv.ParseInt(cmd.Flag("mpu-threshold").Value.String(), 10, 64)
+			if err != nil || threshold < 0 {
+				return fmt.Errorf("invalid mpu-threshold %s", cmd.Flag("mpu-threshold").Value.String())
+			}
+			mo := mpuOptions{
+				threshold:   threshold << 20,
+				partSize:    partSize << 20,
+				concurrency: uploadConcurrency,
+			}
+			if sc.checksum != "" {
+				if sc.checksum, err = parseChecksumAlgorithm(sc.checksum); err != nil {
+					return err
+				}
+			}
+			if len(objectTags) > 0 {
+				tags, err := parseTags(objectTags)
+				if err != nil {
+					return err
+				}
+				sc.tagging = encodeTags(tags)
+			}
+			if err := sc.setLockOptions(objectLockMode, objectRetainUntil); err != nil {
+				return err
+			}
+			if err := sc.checkLockIntegrity(disableContentMd5Validate); err != nil {
+				return err
+			}
+			if cmd.Flag("recursive").Changed { // upload directories
+				if len(args) < 2 {
+					return sc.errorHandler(errors.New("no local directory to upload"))
+				}
+				for _, dir := range args[1:] {
+					err = sc.uploadDir(ctx, bucket, key, dir, objectContentType, metadata, uploadWalk, uploadConcurrency, mo)
+					if err != nil {
+						return sc.errorHandler(err)
+					}
+				}
+				return nil
+			}
+			if len(args) == 2 && args[1] == "-" { // upload from stdin
+				if key == "" || strings.HasSuffix(key, "/") {
+					return sc.errorHandler(errors.New("a Key is required to upload from stdin"))
+				}
+				return sc.errorHandler(sc.putObjectStream(ctx, bucket, key, objectContentType, metadata, mo, os.Stdin))
+			}
 			if len(args) < 2 { // upload one Object
 				if objectContentData != "" { // upload a Object with given content
 					err = sc.putObject(ctx, bucket, key, objectContentType, metadata, stream, strings.NewReader(objectContentData))
@@ -450,7 +813,7 @@ EnvVar:
 				if objectContentType == "" {
 					objectContentType = mime.TypeByExtension(filepath.Ext(args[1]))
 				}
-				err = sc.putObject(ctx, bucket, key, objectContentType, metadata, stream, fd)
+				err = sc.putFile(ctx, bucket, key, objectContentType, metadata, stream, fd, mo)
 			} else { // upload files
 				for _, v := range args[1:] {
 					fd, err = os.Open(v)
@@ -461,7 +824,7 @@ EnvVar:
 						objectContentType = mime.TypeByExtension(filepath.Ext(args[1]))
 					}
 					newKey := key + filepath.Base(v)
-					err = sc.putObject(ctx, bucket, newKey, objectContentType, metadata, stream, fd)
+					err = sc.putFile(ctx, bucket, newKey, objectContentType, metadata, stream, fd, mo)
 					if err != nil {
 						fd.Close()
 						return sc.errorHandler(err)
@@ -476,6 +839,18 @@ EnvVar:
 	uploadObjectCmd.Flags().StringVar(&objectContentData, "data", "", "Object content")
 	uploadObjectCmd.Flags().BoolP("stream", "", false, "stream mode(header Transfer-Encoding: chunked)")
 	uploadObjectCmd.Flags().StringArrayVar(&objectMetadata, "md", nil, "Object user metadata(format Key:Value)")
+	uploadObjectCmd.Flags().BoolP("recursive", "r", false, "upload directories recursively(Key is prefix + relative path)")
+	uploadObjectCmd.Flags().StringArrayVar(&uploadWalk.include, "include", nil, "upload only files match the glob pattern(s) in recursive mode")
+	uploadObjectCmd.Flags().StringArrayVar(&uploadWalk.exclude, "exclude", nil, "skip files and directories match the glob pattern(s) in recursive mode")
+	uploadObjectCmd.Flags().BoolVar(&uploadWalk.followSymlinks, "follow-symlinks", false, "follow symlinks in recursive mode(skipped by default)")
+	uploadObjectCmd.Flags().IntVar(&uploadConcurrency, "concurrency", uploadConcurrency, "upload concurrency num(files in recursive mode, parts in mpu)")
+	uploadObjectCmd.Flags().Int64("part-size", defaultPartSize>>20, "MPU part-size in MB of large uploads")
+	uploadObjectCmd.Flags().Int64("mpu-threshold", defaultMPUThreshold>>20, "upload files(or stdin) larger than mpu-threshold MB with MPU")
+	uploadObjectCmd.Flags().StringVar(&sc.checksum, "checksum-algorithm", "", "send checksum of content(and each MPU part) of algorithm CRC32, CRC32C, SHA1 or SHA256")
+	uploadObjectCmd.Flags().StringArrayVar(&objectTags, "tag", nil, "Object tag(format Key=Value)")
+	uploadObjectCmd.Flags().StringVar(&objectLockMode, "lock-mode", "", "Object Lock retention mode(GOVERNANCE or COMPLIANCE), requires --retain-until")
+	uploadObjectCmd.Flags().StringVar(&objectRetainUntil, "retain-until", "", "Object Lock retain-until date(2006-01-02T15:04:05Z or 2006-01-02)")
+	uploadObjectCmd.Flags().BoolVar(&sc.legalHold, "legal-hold", false, "set Object Lock legal hold ON")
 	rootCmd.AddCommand(uploadObjectCmd)
 
 	headCmd := &cobra.Command{
@@ -501,6 +876,28 @@ EnvVar:
 	headCmd.Flags().BoolP("mtime", "", false, "show Object mtime")
 	rootCmd.AddCommand(headCmd)
 
+	statCmd := &cobra.Command{
+		Use:   "stat <bucket/key>",
+		Short: "show Object attributes(size, ETag, storage class, checksum and parts)",
+		Long: `show Object attributes with GetObjectAttributes(HeadObject if it is not implemented) usage:
+* show attributes of a Object
+	s3cli stat bucket-name/key
+* show attributes of a Object version
+	s3cli stat bucket-name/key --version v1
+* show attributes of a Object in JSON
+	s3cli stat bucket-name/key -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			if key == "" {
+				return sc.errorHandler(errors.New("a Key is required"))
+			}
+			return sc.errorHandler(sc.stat(ctx, bucket, key, cmd.Flag("version").Value.String()))
+		},
+	}
+	statCmd.Flags().String("version", "", "Object version")
+	rootCmd.AddCommand(statCmd)
+
 	aclCmd := &cobra.Command{
 		Use:   "acl <bucket/key> [ACL]",
 		Short: "get/set Bucket/Object ACL",
@@ -566,6 +963,47 @@ EnvVar:
 	}
 	rootCmd.AddCommand(aclCmd)
 
+	var tagDelete bool
+	tagCmd := &cobra.Command{
+		Use:   "tag <bucket/key> [Key=Value ...]",
+		Short: "get/set/delete Object tags",
+		Long: `get/set/delete Object tags usage:
+* get Object tags
+	s3cli tag bucket-name/key
+* set(replace) Object tags
+	s3cli tag bucket-name/key env=prod team=storage
+* delete Object tags
+	s3cli tag bucket-name/key --delete
+* get tags of a Object version
+	s3cli tag bucket-name/key --version versionID
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			if key == "" {
+				return sc.errorHandler(errors.New("a Key is required"))
+			}
+			version := cmd.Flag("version").Value.String()
+			if tagDelete {
+				if len(args) > 1 {
+					return errors.New("--delete does not take tags")
+				}
+				return sc.errorHandler(sc.deleteObjectTagging(ctx, bucket, key, version))
+			}
+			if len(args) == 1 {
+				return sc.errorHandler(sc.getObjectTagging(ctx, bucket, key, version))
+			}
+			tags, err := parseTags(args[1:])
+			if err != nil {
+				return err
+			}
+			return sc.errorHandler(sc.putObjectTagging(ctx, bucket, key, version, tags))
+		},
+	}
+	tagCmd.Flags().String("version", "", "Object version")
+	tagCmd.Flags().BoolVar(&tagDelete, "delete", false, "delete all tags")
+	rootCmd.AddCommand(tagCmd)
+
 	//aws --endpoint-url http://172.16.3.98:9020 --profile ak1 s3api list-buckets
 	//aws --endpoint-url http://172.16.3.98:9020 --profile ak1 s3api list-objects --bucket mybucket
 	var listMaxKeys int64
@@ -739,6 +1177,8 @@ EnvVar:
 	}
 	rootCmd.AddCommand(restoreObjectCmd)
 
+	var downloadDir = "."
+	var downloadConcurrency = s3manager.DefaultDownloadConcurrency
 	downloadObjectCmd := &cobra.Command{
 		Use:     "download <bucket/key> [key...]",
 		Aliases: []string{"get"},
@@ -748,6 +1188,20 @@ EnvVar:
 	s3cli download bucket-name/key
 * download Objects to ./
 	s3cli download bucket-name/key key2 key3
+* download Objects to /tmp/
+	s3cli download bucket-name/key key2 key3 --dir /tmp
+* download a Object and overwrite the local file if it exists
+	s3cli download bucket-name/key --overwrite
+* download a large Object in parallel with 16MB ranged GETs, 8 parts in flight
+	s3cli download bucket-name/key --part-size 16 --concurrency 8
+* download a large Object and resume it if it was interrupted before
+	s3cli download bucket-name/key --continue
+* download a Object and validate it with the checksum stored with the Object
+	s3cli download bucket-name/key --checksum
+* download all Objects with prefix(logs/2024/) to ./2024/
+	s3cli download bucket-name/logs/2024 --recursive
+* download all Objects with prefix(logs/2024/) to /tmp/
+	s3cli download bucket-name/logs/2024/ --recursive --dir /tmp --concurrency 8
 * presign(V4) a download Object URL
 	s3cli download bucket-name/key --presign`,
 		Args: cobra.MinimumNArgs(1),
@@ -755,13 +1209,30 @@ EnvVar:
 			bucket, key := sc.splitKeyValue(args[0], "/")
 			objRange := cmd.Flag("range").Value.String()
 			version := cmd.Flag("version").Value.String()
-			err := sc.getObject(ctx, bucket, key, objRange, version)
+			partSize, err := strconv.ParseInt(cmd.Flag("part-size").Value.String(), 10, 64)
+			if err != nil || partSize < 0 {
+				return fmt.Errorf("invalid part-size %s", cmd.Flag("part-size").Value.String())
+			}
+			if partSize > 0 && cmd.Flag("continue").Changed {
+				return errors.New("--continue can not be used with --part-size")
+			}
+			opt := downloadOptions{
+				overwrite:   cmd.Flag("overwrite").Changed,
+				partSize:    partSize << 20,
+				concurrency: downloadConcurrency,
+				resume:      cmd.Flag("continue").Changed,
+				checksum:    cmd.Flag("checksum").Changed,
+			}
+			if cmd.Flag("recursive").Changed {
+				return sc.errorHandler(sc.downloadPrefix(ctx, bucket, key, downloadDir, downloadConcurrency, opt))
+			}
+			err = sc.getObject(ctx, bucket, key, objRange, version, filepath.Join(downloadDir, filepath.Base(key)), opt)
 			if err != nil {
 				return sc.errorHandler(err)
 			}
 			if len(args) > 1 {
 				for _, k := range args[1:] {
-					err := sc.getObject(ctx, bucket, k, "", "")
+					err := sc.getObject(ctx, bucket, k, "", "", filepath.Join(downloadDir, filepath.Base(k)), opt)
 					if err != nil {
 						return sc.errorHandler(err)
 					}
@@ -773,6 +1244,12 @@ EnvVar:
 	downloadObjectCmd.Flags().StringP("range", "r", "", "Object range to download, 0-64 means [0, 64]")
 	downloadObjectCmd.Flags().StringP("version", "", "", "Object version to download")
 	downloadObjectCmd.Flags().BoolP("overwrite", "w", false, "overwrite local file if exist")
+	downloadObjectCmd.Flags().BoolP("recursive", "", false, "download all Objects with specified prefix and recreate the Key hierarchy")
+	downloadObjectCmd.Flags().StringVarP(&downloadDir, "dir", "d", downloadDir, "local directory to download to")
+	downloadObjectCmd.Flags().BoolP("continue", "c", false, "resume the partial download(<file>.part) of the same Object")
+	downloadObjectCmd.Flags().Bool("checksum", false, "validate the download with the checksum(ChecksumMode=ENABLED) stored with the Object")
+	downloadObjectCmd.Flags().Int64("part-size", 0, "download parts of part-size MB in parallel(0 to download with a single GET)")
+	downloadObjectCmd.Flags().IntVar(&downloadConcurrency, "concurrency", downloadConcurrency, "download concurrency num(Objects in recursive mode, parts if --part-size is set)")
 	rootCmd.AddCommand(downloadObjectCmd)
 
 	catObjectCmd := &cobra.Command{
@@ -801,21 +1278,30 @@ EnvVar:
 * specify destination key
 	s3cli mv bucket-name/key1 bucket-name2/key2
 * default destination key
-	s3cli mv bucket-name/key1 bucket-name2`,
+	s3cli mv bucket-name/key1 bucket-name2
+* rename all Objects with prefix(dir/) to prefix(newdir/)
+	s3cli mv bucket-name/dir/ bucket-name2/newdir/ --prefix`,
 		Args: cobra.ExactArgs(2),
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srcBucket, srcKey := sc.splitKeyValue(args[0], "/")
 			bucket, key := sc.splitKeyValue(args[1], "/")
 			if key == "" {
-				_, key = sc.splitKeyValue(args[0], "/")
+				key = srcKey
+			}
+			if cmd.Flag("prefix").Changed {
+				return sc.errorHandler(sc.renamePrefix(ctx, srcBucket, srcKey, bucket, key))
 			}
 			return sc.errorHandler(sc.renameObject(ctx, args[0], bucket, key))
 		},
 	}
+	renameObjectCmd.Flags().BoolP("prefix", "", false, "rename all Objects start with specified prefix")
 	rootCmd.AddCommand(renameObjectCmd)
 
 	var copyObjectReplaceMetadata bool
+	var copyTaggingDirective string
+	var copyConcurrency = s3manager.DefaultUploadConcurrency
 	copyObjectCmd := &cobra.Command{
-		Use:     "copy <bucket/key> <bucket/key>",
+		Use:     "copy <[profile:]bucket/key> <[profile:]bucket/key>",
 		Aliases: []string{"cp"},
 		Short:   "copy Object",
 		Long: `copy Bucket/key to Bucket/key usage:
@@ -824,9 +1310,17 @@ EnvVar:
 * specify destination Bucket
 	s3cli copy bucket-src/key-src bucket-dst/
 * specify destination Key
-	s3cli copy bucket-src/key-src key-dst`,
+	s3cli copy bucket-src/key-src key-dst
+* copy a Object between two S3 services(profiles in ~/.aws/credentials, endpoint_url in ~/.aws/config)
+	s3cli copy old-cluster:bucket-src/key new-cluster:bucket-dst/key
+* copy a Object larger than 5GB with UploadPartCopy in parts of 1GB
+	s3cli copy bucket-src/vm.img bucket-dst/ --part-size 1024 --concurrency 8
+* copy a Object and replace its tags(tagging-directive REPLACE)
+	s3cli copy bucket-src/key bucket-dst/key --tag env=prod --tag team=storage
+* copy a Object without its tags
+	s3cli copy bucket-src/key bucket-dst/key --tagging-directive REPLACE`,
 		Args: cobra.ExactArgs(2),
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			var metadata map[string]*string
 			for _, v := range objectMetadata {
 				k, v := sc.splitKeyValue(v, ":")
@@ -837,27 +1331,140 @@ EnvVar:
 					metadata[k] = &v
 				}
 			}
-			srcBucket, srcKey := sc.splitKeyValue(args[0], "/")
+			srcProfile, source := sc.splitProfile(args[0])
+			dstProfile, target := sc.splitProfile(args[1])
+			srcBucket, srcKey := sc.splitKeyValue(source, "/")
 			dstBucket := ""
 			dstKey := ""
-			if !strings.Contains(args[1], "/") {
+			if !strings.Contains(target, "/") {
 				dstBucket = srcBucket
-				dstKey = args[1]
+				dstKey = target
 			} else {
-				dstBucket, dstKey = sc.splitKeyValue(args[1], "/")
+				dstBucket, dstKey = sc.splitKeyValue(target, "/")
 				if dstKey == "" {
 					dstKey = srcKey
 				}
 			}
 
-			return sc.errorHandler(sc.copyObject(ctx, args[0], dstBucket, dstKey, objectContentType, metadata, copyObjectReplaceMetadata))
+			partSize, err := strconv.ParseInt(cmd.Flag("part-size").Value.String(), 10, 64)
+			if err != nil || partSize < 1 {
+				return fmt.Errorf("invalid part-size %s", cmd.Flag("part-size").Value.String())
+			}
+			threshold, err := strconv.ParseInt(cmd.Flag("mpu-threshold").Value.String(), 10, 64)
+			if err != nil || threshold < 0 {
+				return fmt.Errorf("invalid mpu-threshold %s", cmd.Flag("mpu-threshold").Value.String())
+			}
+			mo := mpuOptions{
+				threshold:   threshold << 20,
+				partSize:    partSize << 20,
+				concurrency: copyConcurrency,
+			}
+			if len(objectTags) > 0 {
+				tags, err := parseTags(objectTags)
+				if err != nil {
+					return err
+				}
+				sc.tagging = encodeTags(tags)
+			}
+			switch strings.ToUpper(copyTaggingDirective) {
+			case "":
+				sc.tagReplace = len(objectTags) > 0
+			case s3.TaggingDirectiveCopy:
+				if len(objectTags) > 0 {
+					return errors.New("--tag requires tagging-directive REPLACE")
+				}
+			case s3.TaggingDirectiveReplace:
+				sc.tagReplace = true
+			default:
+				return fmt.Errorf("invalid tagging-directive %s", copyTaggingDirective)
+			}
+			src, err := sc.profileCli(srcProfile)
+			if err != nil {
+				return sc.errorHandler(err)
+			}
+			dst, err := sc.profileCli(dstProfile)
+			if err != nil {
+				return sc.errorHandler(err)
+			}
+			if srcProfile != dstProfile {
+				return sc.errorHandler(dst.copyObjectAcross(ctx, src, srcBucket, srcKey, dstBucket, dstKey, objectContentType, metadata, copyObjectReplaceMetadata, mo))
+			}
+			return sc.errorHandler(dst.copyObjectAuto(ctx, source, dstBucket, dstKey, objectContentType, metadata, copyObjectReplaceMetadata, mo))
 		},
 	}
 	copyObjectCmd.Flags().StringArrayVar(&objectMetadata, "md", nil, "new Object user metadata(format Key:Value)")
 	copyObjectCmd.Flags().StringVar(&objectContentType, "content-type", "", "new Object Content-Type")
 	copyObjectCmd.Flags().BoolVar(&copyObjectReplaceMetadata, "replace-md", false, "replace metadata(must be true if src and dst is the same file)")
+	copyObjectCmd.Flags().Int64("mpu-threshold", defaultCopyMPUThreshold>>20, "copy Object larger than mpu-threshold MB with multipart copy(UploadPartCopy)")
+	copyObjectCmd.Flags().Int64("part-size", defaultCopyPartSize>>20, "multipart copy part-size in MB")
+	copyObjectCmd.Flags().IntVar(&copyConcurrency, "concurrency", copyConcurrency, "multipart copy concurrency num")
+	copyObjectCmd.Flags().StringArrayVar(&objectTags, "tag", nil, "new Object tag(format Key=Value), implies tagging-directive REPLACE")
+	copyObjectCmd.Flags().StringVar(&copyTaggingDirective, "tagging-directive", "", "COPY the tags of source Object or REPLACE them with --tag(default COPY, REPLACE with --tag)")
 	rootCmd.AddCommand(copyObjectCmd)
 
+	var syncConcurrency = s3manager.DefaultUploadConcurrency
+	syncCmd := &cobra.Command{
+		Use:   "sync <source> <destination>",
+		Short: "sync local directory and Bucket",
+		Long: `one-way sync source to destination usage:
+(local directory must be an absolute path or start with '.', otherwise it is [profile:]bucket[/prefix])
+* sync local directory to Bucket with prefix(dir/)
+	s3cli sync ./build bucket-name/dir/
+* sync Bucket prefix(dir/) to local directory
+	s3cli sync bucket-name/dir/ ./build
+* sync Bucket to another Bucket
+	s3cli sync bucket-name bucket-name2/backup/
+* sync Bucket to a Bucket of another S3 service(profile new-cluster)
+	s3cli sync bucket-name new-cluster:bucket-name
+* sync and delete Objects not exist in local directory
+	s3cli sync ./build bucket-name/dir/ --delete
+* show what would be synced
+	s3cli sync ./build bucket-name/dir/ --delete --dry-run`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src := sc.parseSyncLocation(args[0])
+			dst := sc.parseSyncLocation(args[1])
+			del := cmd.Flag("delete").Changed
+			dryRun := cmd.Flag("dry-run").Changed
+			return sc.errorHandler(sc.syncDir(ctx, src, dst, del, dryRun, syncConcurrency))
+		},
+	}
+	syncCmd.Flags().BoolP("delete", "", false, "delete destination files or Objects not exist in source")
+	syncCmd.Flags().BoolP("dry-run", "", false, "print what would be synced and exit")
+	syncCmd.Flags().IntVar(&syncConcurrency, "concurrency", syncConcurrency, "sync concurrency num")
+	rootCmd.AddCommand(syncCmd)
+
+	var verifyWalk walkOptions
+	var verifyConcurrency = s3manager.DefaultUploadConcurrency
+	verifyCmd := &cobra.Command{
+		Use:   "verify <bucket/key> <file|dir>",
+		Short: "verify local file(s) match Object(s)",
+		Long: `verify local file(s) match Object(s) by ETag(MD5, or MD5 of part MD5s of multipart Object) usage:
+* verify a file against a Object
+	s3cli verify bucket-name/key /path/to/file
+* verify a file uploaded with mpu in parts of 64MB(inferred if not set)
+	s3cli verify bucket-name/key /path/to/file --part-size 64
+* verify a directory against Objects with prefix(dir/)
+	s3cli verify bucket-name/dir/ /path/to/dir
+* verify *.go files in a directory, skip the vendor directory
+	s3cli verify bucket-name/dir/ /path/to/dir --include '*.go' --exclude 'vendor/*'`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			partSize, err := strconv.ParseInt(cmd.Flag("part-size").Value.String(), 10, 64)
+			if err != nil || partSize < 0 {
+				return fmt.Errorf("invalid part-size %s", cmd.Flag("part-size").Value.String())
+			}
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			return sc.errorHandler(sc.verify(ctx, bucket, key, args[1], verifyWalk, partSize<<20, verifyConcurrency))
+		},
+	}
+	verifyCmd.Flags().Int64("part-size", 0, "part-size in MB of multipart Object(0 to infer from the Object)")
+	verifyCmd.Flags().StringArrayVar(&verifyWalk.include, "include", nil, "verify only files match the glob pattern(s)")
+	verifyCmd.Flags().StringArrayVar(&verifyWalk.exclude, "exclude", nil, "skip files and directories match the glob pattern(s)")
+	verifyCmd.Flags().BoolVar(&verifyWalk.followSymlinks, "follow-symlinks", false, "follow symlinks(skipped by default)")
+	verifyCmd.Flags().IntVar(&verifyConcurrency, "concurrency", verifyConcurrency, "verify concurrency num")
+	rootCmd.AddCommand(verifyCmd)
+
 	deleteObjectCmd := &cobra.Command{
 		Use:     "delete <bucket/key> [key...]",
 		Aliases: []string{"rm"},
@@ -903,13 +1510,21 @@ EnvVar:
 		Aliases: []string{"mi"},
 		Long: `create a multiPartUpload request usage:
 * init(create) a MPU request
-	s3cli mpu-init bucket-name/key`,
+	s3cli mpu-init bucket-name/key
+* init(create) a MPU request of parts with checksum SHA256
+	s3cli mpu-init bucket-name/key --checksum-algorithm SHA256`,
 		Args: cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			if sc.checksum != "" {
+				if sc.checksum, err = parseChecksumAlgorithm(sc.checksum); err != nil {
+					return err
+				}
+			}
 			bucket, key := sc.splitKeyValue(args[0], "/")
 			return sc.errorHandler(sc.mpuCreate(ctx, bucket, key))
 		},
 	}
+	mpuCreateCmd.Flags().StringVar(&sc.checksum, "checksum-algorithm", "", "checksum algorithm(CRC32, CRC32C, SHA1 or SHA256) of the parts")
 	rootCmd.AddCommand(mpuCreateCmd)
 
 	mpuUploadCmd := &cobra.Command{
@@ -922,9 +1537,16 @@ EnvVar:
 * upload MPU part2
 	s3cli mpu-upload bucket-name/key UploadId 2:localfile2
 * upload MPU part3 and part4
-	s3cli mpu-upload bucket-name/key UploadId 3:localfile3 4:localfile4`,
+	s3cli mpu-upload bucket-name/key UploadId 3:localfile3 4:localfile4
+* upload MPU part1 with checksum SHA256(the algorithm of mpu-init)
+	s3cli mpu-upload bucket-name/key UploadId 1:localfile1 --checksum-algorithm SHA256`,
 		Args: cobra.MinimumNArgs(3),
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(_ *cobra.Command, args []string) (err error) {
+			if sc.checksum != "" {
+				if sc.checksum, err = parseChecksumAlgorithm(sc.checksum); err != nil {
+					return err
+				}
+			}
 			files := map[int64]string{}
 			for _, v := range args[2:] {
 				i, filename := sc.splitKeyValue(v, ":")
@@ -942,6 +1564,7 @@ EnvVar:
 			return sc.errorHandler(sc.mpuUpload(ctx, bucket, key, args[1], files))
 		},
 	}
+	mpuUploadCmd.Flags().StringVar(&sc.checksum, "checksum-algorithm", "", "send checksum of each part of algorithm CRC32, CRC32C, SHA1 or SHA256")
 	rootCmd.AddCommand(mpuUploadCmd)
 
 	mpuAbortCmd := &cobra.Command{
@@ -983,14 +1606,59 @@ EnvVar:
 	}
 	rootCmd.AddCommand(mpuListCmd)
 
+	mpuPartsCmd := &cobra.Command{
+		Use:     "mpu-parts <bucket/key> <UploadId>",
+		Aliases: []string{"mp"},
+		Short:   "list uploaded parts of a MPU",
+		Long: `list uploaded parts of a multiPartUpload usage:
+* list MPU parts
+	s3cli mpu-parts bucket-name/key UploadId`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			if bucket == "" {
+				return sc.errorHandler(fmt.Errorf("unknown bucket <bucket/key>(%v)", args[0]))
+			}
+			if key == "" {
+				return sc.errorHandler(fmt.Errorf("unknown key <bucket/key>(%v)", args[0]))
+			}
+			return sc.errorHandler(sc.mpuParts(ctx, bucket, key, args[1]))
+		},
+	}
+	rootCmd.AddCommand(mpuPartsCmd)
+
+	var mpuCleanOlderThan = 7 * 24 * time.Hour
+	mpuCleanCmd := &cobra.Command{
+		Use:   "mpu-clean <bucket[/prefix]>",
+		Short: "abort stale MPU(s)",
+		Long: `abort stale multiPartUploads usage:
+* show MPUs initiated more than 7 days ago
+	s3cli mpu-clean bucket-name --dry-run
+* abort MPUs with prefix(logs/) initiated more than 1 day ago
+	s3cli mpu-clean bucket-name/logs/ --older-than 24h`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, prefix := sc.splitKeyValue(args[0], "/")
+			if bucket == "" {
+				return sc.errorHandler(fmt.Errorf("unknown bucket <bucket/prefix>(%v)", args[0]))
+			}
+			return sc.errorHandler(sc.mpuClean(ctx, bucket, prefix, mpuCleanOlderThan, cmd.Flag("dry-run").Changed))
+		},
+	}
+	mpuCleanCmd.Flags().DurationVar(&mpuCleanOlderThan, "older-than", mpuCleanOlderThan, "abort MPUs initiated more than older-than ago")
+	mpuCleanCmd.Flags().Bool("dry-run", false, "show stale MPUs only, abort nothing")
+	rootCmd.AddCommand(mpuCleanCmd)
+
 	mpuCompleteCmd := &cobra.Command{
-		Use:     "mpu-complete <bucket/key> <UploadId> <part-etag> [<part-etag> ...]",
+		Use:     "mpu-complete <bucket/key> <UploadId> [<part-etag> ...]",
 		Short:   "complete a MPU request",
 		Aliases: []string{"mc"},
 		Long: `complete a mutiPartUpload request usage:
 * complete a MPU request
-	s3cli mpu-complete bucket-name/key UploadId etag01 etag02 etag03`,
-		Args: cobra.MinimumNArgs(3),
+	s3cli mpu-complete bucket-name/key UploadId etag01 etag02 etag03
+* complete a MPU request with all uploaded parts
+	s3cli mpu-complete bucket-name/key UploadId`,
+		Args: cobra.MinimumNArgs(2),
 		RunE: func(_ *cobra.Command, args []string) error {
 			bucket, key := sc.splitKeyValue(args[0], "/")
 			if bucket == "" {
@@ -1017,6 +1685,14 @@ EnvVar:
 	s3cli mpu bucket /path/to/file
 * mpu a file to Bucket/Key
 	s3cli mpu bucket-name/key /path/to/file
+* mpu a large file, run it again to upload only the missing parts if it was interrupted
+	s3cli mpu bucket-name/key /path/to/file --resume
+* mpu a file with SHA256 checksum of each part
+	s3cli mpu bucket-name/key /path/to/file --checksum-algorithm SHA256
+* mpu a file with tags
+	s3cli mpu bucket-name/key /path/to/file --tag env=prod
+* mpu a file locked(Object Lock) in GOVERNANCE mode until 2030-01-01
+	s3cli mpu bucket-name/key /path/to/file --lock-mode GOVERNANCE --retain-until 2030-01-01
 `,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
@@ -1033,9 +1709,40 @@ EnvVar:
 				}
 			}
 			partSize, err := strconv.ParseInt(cmd.Flag("part-size").Value.String(), 10, 64)
-			if err != nil {
+			if err != nil || partSize < 1 {
 				return fmt.Errorf("invalid part-size %s", cmd.Flag("part-size").Value.String())
 			}
+			if sc.checksum != "" {
+				if sc.checksum, err = parseChecksumAlgorithm(sc.checksum); err != nil {
+					return err
+				}
+			}
+			if len(objectTags) > 0 {
+				tags, err := parseTags(objectTags)
+				if err != nil {
+					return err
+				}
+				sc.tagging = encodeTags(tags)
+			}
+			if err := sc.setLockOptions(objectLockMode, objectRetainUntil); err != nil {
+				return err
+			}
+			if err := sc.checkLockIntegrity(disableContentMd5Validate); err != nil {
+				return err
+			}
+			if cmd.Flag("resume").Changed {
+				if objectContentType == "" {
+					objectContentType = mime.TypeByExtension(filepath.Ext(args[1]))
+				}
+				if key == "" {
+					key = filepath.Base(args[1])
+				}
+				journal := cmd.Flag("journal").Value.String()
+				if journal == "" {
+					journal = args[1] + ".mpu.json"
+				}
+				return sc.errorHandler(sc.mpuResume(ctx, bucket, key, objectContentType, args[1], journal, partSize<<20, mpuConcurrency, metadata))
+			}
 
 			fd, err = os.Open(args[1])
 			if err != nil {
@@ -1057,7 +1764,14 @@ EnvVar:
 	mpuCmd.Flags().StringVar(&objectContentType, "content-type", "", "Object content-type(auto detect if not specified)")
 	mpuCmd.Flags().Int64("part-size", s3manager.MinUploadPartSize>>20, "MPU part-size in MB")
 	mpuCmd.Flags().IntVar(&mpuConcurrency, "concurrency", mpuConcurrency, "MPU concurrency num")
+	mpuCmd.Flags().Bool("resume", false, "record the MPU in a journal and resume it if it was interrupted before")
+	mpuCmd.Flags().String("journal", "", "MPU journal file of --resume(default <file>.mpu.json)")
 	mpuCmd.Flags().StringArrayVar(&objectMetadata, "md", nil, "Object user metadata(format Key:Value)")
+	mpuCmd.Flags().StringVar(&sc.checksum, "checksum-algorithm", "", "send checksum of each part of algorithm CRC32, CRC32C, SHA1 or SHA256")
+	mpuCmd.Flags().StringArrayVar(&objectTags, "tag", nil, "Object tag(format Key=Value)")
+	mpuCmd.Flags().StringVar(&objectLockMode, "lock-mode", "", "Object Lock retention mode(GOVERNANCE or COMPLIANCE), requires --retain-until")
+	mpuCmd.Flags().StringVar(&objectRetainUntil, "retain-until", "", "Object Lock retain-until date(2006-01-02T15:04:05Z or 2006-01-02)")
+	mpuCmd.Flags().BoolVar(&sc.legalHold, "legal-hold", false, "set Object Lock legal hold ON")
 	rootCmd.AddCommand(mpuCmd)
 
 	//aws s3api --endpoint-url http://172.16.3.98:9020 --profile ak1 get-object-lock-configuration --bucket mybucket
@@ -1077,6 +1791,8 @@ EnvVar:
 	}
 	rootCmd.AddCommand(getObjectLockConfigCmd)
 
+	var lockConfigMode string
+	var lockConfigDays, lockConfigYears int64
 	putObjectLockConfigCmd := &cobra.Command{
 		Use:     "put-object-lock-configuration <bucket>",
 		Aliases: []string{"polc"},
@@ -1086,15 +1802,107 @@ EnvVar:
 	s3cli put-object-lock-configuration bucket Enabled
 * Disable a Bucket lock configuration
 	s3cli put-object-lock-configuration bucket Disable
+* Enable a Bucket lock configuration with default retention of 30 days in GOVERNANCE mode
+	s3cli put-object-lock-configuration bucket Enabled --mode GOVERNANCE --days 30
+* Enable a Bucket lock configuration with default retention of 7 years in COMPLIANCE mode
+	s3cli put-object-lock-configuration bucket Enabled --mode COMPLIANCE --years 7
 `,
 		Args: cobra.ExactArgs(2),
 		RunE: func(_ *cobra.Command, args []string) (err error) {
-			err = sc.putObjectLockConfig(ctx, args[0], args[1])
+			if lockConfigMode != "" {
+				if lockConfigMode, err = parseLockMode(lockConfigMode); err != nil {
+					return err
+				}
+				if (lockConfigDays > 0) == (lockConfigYears > 0) {
+					return errors.New("default retention requires one of --days or --years")
+				}
+			} else if lockConfigDays > 0 || lockConfigYears > 0 {
+				return errors.New("default retention requires --mode")
+			}
+			err = sc.putObjectLockConfig(ctx, args[0], args[1], lockConfigMode, lockConfigDays, lockConfigYears)
 			return sc.errorHandler(err)
 		},
 	}
+	putObjectLockConfigCmd.Flags().StringVar(&lockConfigMode, "mode", "", "default retention mode(GOVERNANCE or COMPLIANCE)")
+	putObjectLockConfigCmd.Flags().Int64Var(&lockConfigDays, "days", 0, "default retention days")
+	putObjectLockConfigCmd.Flags().Int64Var(&lockConfigYears, "years", 0, "default retention years")
 	rootCmd.AddCommand(putObjectLockConfigCmd)
 
+	var retentionBypass bool
+	retentionCmd := &cobra.Command{
+		Use:   "retention <bucket/key> [mode retain-until]",
+		Short: "get/set Object retention(Object Lock)",
+		Long: `get/set Object retention(Object Lock) usage:
+* get Object retention
+	s3cli retention bucket-name/key
+* get retention of a Object version
+	s3cli retention bucket-name/key --version versionID
+* lock a Object in COMPLIANCE mode until 2030-01-01(UTC)
+	s3cli retention bucket-name/key COMPLIANCE 2030-01-01
+* shorten the GOVERNANCE retention of a Object version
+	s3cli retention bucket-name/key GOVERNANCE 2026-12-31T00:00:00Z --version versionID --bypass-governance
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 && len(args) != 3 {
+				return fmt.Errorf("accepts 1 or 3 arg(s), received %d", len(args))
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			if key == "" {
+				return sc.errorHandler(errors.New("a Key is required"))
+			}
+			version := cmd.Flag("version").Value.String()
+			if len(args) == 1 {
+				return sc.errorHandler(sc.getObjectRetention(ctx, bucket, key, version))
+			}
+			mode, err := parseLockMode(args[1])
+			if err != nil {
+				return err
+			}
+			until, err := parseRetainUntil(args[2])
+			if err != nil {
+				return err
+			}
+			return sc.errorHandler(sc.putObjectRetention(ctx, bucket, key, version, mode, until, retentionBypass))
+		},
+	}
+	retentionCmd.Flags().String("version", "", "Object version")
+	retentionCmd.Flags().BoolVar(&retentionBypass, "bypass-governance", false, "bypass GOVERNANCE mode to shorten the retention")
+	rootCmd.AddCommand(retentionCmd)
+
+	legalHoldCmd := &cobra.Command{
+		Use:   "legal-hold <bucket/key> [ON|OFF]",
+		Short: "get/set Object legal hold(Object Lock)",
+		Long: `get/set Object legal hold(Object Lock) usage:
+* get Object legal hold
+	s3cli legal-hold bucket-name/key
+* set legal hold of a Object
+	s3cli legal-hold bucket-name/key ON
+* remove legal hold of a Object version
+	s3cli legal-hold bucket-name/key OFF --version versionID
+`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bucket, key := sc.splitKeyValue(args[0], "/")
+			if key == "" {
+				return sc.errorHandler(errors.New("a Key is required"))
+			}
+			version := cmd.Flag("version").Value.String()
+			if len(args) == 1 {
+				return sc.errorHandler(sc.getObjectLegalHold(ctx, bucket, key, version))
+			}
+			status, err := parseLegalHold(args[1])
+			if err != nil {
+				return err
+			}
+			return sc.errorHandler(sc.putObjectLegalHold(ctx, bucket, key, version, status))
+		},
+	}
+	legalHoldCmd.Flags().String("version", "", "Object version")
+	rootCmd.AddCommand(legalHoldCmd)
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
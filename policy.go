@@ -0,0 +1,843 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// policyVersions are the valid Versions of policy language
+var policyVersions = []string{"2012-10-17", "2008-10-17"}
+
+// policyDocument is a Bucket policy
+type policyDocument struct {
+	Version   string           `json:"Version"`
+	ID        string           `json:"Id,omitempty"`
+	Statement policyStatements `json:"Statement"`
+}
+
+// policyStatement is a statement of Bucket policy
+type policyStatement struct {
+	Sid          string          `json:"Sid,omitempty"`
+	Effect       string          `json:"Effect"`
+	Principal    policyPrincipal `json:"Principal,omitempty"`
+	NotPrincipal policyPrincipal `json:"NotPrincipal,omitempty"`
+	Action       stringOrSlice   `json:"Action,omitempty"`
+	NotAction    stringOrSlice   `json:"NotAction,omitempty"`
+	Resource     stringOrSlice   `json:"Resource,omitempty"`
+	NotResource  stringOrSlice   `json:"NotResource,omitempty"`
+	// Condition is operator(e.g. StringEquals) -> condition key(e.g. aws:SourceIp) -> values
+	Condition map[string]map[string]stringOrSlice `json:"Condition,omitempty"`
+}
+
+// policyStatements is a statement or a list of statements
+type policyStatements []policyStatement
+
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var st policyStatement
+		if err := strictUnmarshal(data, &st); err != nil {
+			return err
+		}
+		*s = policyStatements{st}
+		return nil
+	}
+	var sts []policyStatement
+	if err := strictUnmarshal(data, &sts); err != nil {
+		return err
+	}
+	*s = sts
+	return nil
+}
+
+// stringOrSlice is a string or a list of strings, booleans and numbers are converted to strings
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	values, ok := v.([]interface{})
+	if !ok {
+		values = []interface{}{v}
+	}
+	*s = make(stringOrSlice, 0, len(values))
+	for _, value := range values {
+		switch value.(type) {
+		case string, bool, float64:
+			*s = append(*s, fmt.Sprint(value))
+		default:
+			return fmt.Errorf("invalid value %s(string or list of strings)", data)
+		}
+	}
+	return nil
+}
+
+func (s stringOrSlice) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+// policyPrincipal is principal type(e.g. AWS) -> principals, "*" is {"*": ["*"]}
+type policyPrincipal map[string]stringOrSlice
+
+func (p *policyPrincipal) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "*" {
+			return fmt.Errorf("invalid principal %s(\"*\" or {\"AWS\": ...})", s)
+		}
+		*p = policyPrincipal{"*": {"*"}}
+		return nil
+	}
+	var m map[string]stringOrSlice
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid principal %s(\"*\" or {\"AWS\": ...})", data)
+	}
+	*p = m
+	return nil
+}
+
+func (p policyPrincipal) MarshalJSON() ([]byte, error) {
+	if len(p) == 1 && slices.Equal(p["*"], stringOrSlice{"*"}) {
+		return json.Marshal("*")
+	}
+	return json.Marshal(map[string]stringOrSlice(p))
+}
+
+// strictUnmarshal unmarshals data to v, unknown fields(e.g. misspelled Statement) are errors
+func strictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// parsePolicy parses a Bucket policy in JSON
+func parsePolicy(data []byte) (*policyDocument, error) {
+	doc := &policyDocument{}
+	if err := strictUnmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("invalid policy JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// validatePolicy checks the structure of doc, and that the resources are in bucket if bucket is not empty
+func validatePolicy(doc *policyDocument, bucket string) error {
+	if !slices.Contains(policyVersions, doc.Version) {
+		return fmt.Errorf("invalid Version %q(%s)", doc.Version, strings.Join(policyVersions, " or "))
+	}
+	if len(doc.Statement) == 0 {
+		return errors.New("no Statement")
+	}
+	var sids []string
+	for i, st := range doc.Statement {
+		name := fmt.Sprintf("#%d", i+1)
+		if st.Sid != "" {
+			if slices.Contains(sids, st.Sid) {
+				return fmt.Errorf("Statement %s: duplicate Sid", st.Sid)
+			}
+			sids = append(sids, st.Sid)
+			name = st.Sid
+		}
+		if err := validateStatement(&st, bucket); err != nil {
+			return fmt.Errorf("Statement %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateStatement checks the Effect, Principal, Action, Resource and Condition of a statement
+func validateStatement(st *policyStatement, bucket string) error {
+	if st.Effect != "Allow" && st.Effect != "Deny" {
+		return fmt.Errorf("invalid Effect %q(Allow or Deny)", st.Effect)
+	}
+	if (len(st.Principal) == 0) == (len(st.NotPrincipal) == 0) {
+		return errors.New("one of Principal or NotPrincipal is required")
+	}
+	for _, principal := range []policyPrincipal{st.Principal, st.NotPrincipal} {
+		for k, v := range principal {
+			if len(v) == 0 {
+				return fmt.Errorf("no %s Principal", k)
+			}
+		}
+	}
+	if (len(st.Action) == 0) == (len(st.NotAction) == 0) {
+		return errors.New("one of Action or NotAction is required")
+	}
+	for _, action := range append(slices.Clone(st.Action), st.NotAction...) {
+		action = strings.ToLower(action) // action is case insensitive, e.g. S3:GetObject
+		if action != "*" && !strings.HasPrefix(action, "s3:") && !strings.HasPrefix(action, "s3-object-lambda:") {
+			return fmt.Errorf("invalid Action %s(s3:<action> or *)", action)
+		}
+	}
+	if (len(st.Resource) == 0) == (len(st.NotResource) == 0) {
+		return errors.New("one of Resource or NotResource is required")
+	}
+	for _, resource := range append(slices.Clone(st.Resource), st.NotResource...) {
+		if resource == "*" {
+			continue
+		}
+		name, ok := s3ARNResource(resource)
+		if !ok {
+			return fmt.Errorf("invalid Resource %s(arn:<partition>:s3:::<bucket>[/<key>])", resource)
+		}
+		if b, _, _ := strings.Cut(name, "/"); bucket != "" && !wildcardMatch(b, bucket) {
+			return fmt.Errorf("Resource %s is not in Bucket %s", resource, bucket)
+		}
+	}
+	for op, conditions := range st.Condition {
+		if len(conditions) == 0 {
+			return fmt.Errorf("empty Condition %s", op)
+		}
+		for k, v := range conditions {
+			if len(v) == 0 {
+				return fmt.Errorf("Condition %s %s has no value", op, k)
+			}
+		}
+	}
+	return nil
+}
+
+// s3ARNResource returns the <bucket>[/<key>] of S3 ARN arn:<partition>:s3:<region>:<account>:<bucket>[/<key>],
+// false if arn is not a S3 ARN. Region and account are empty on AWS, the account is the tenant on Ceph,
+// e.g. arn:aws:s3::tenant:bucket
+func s3ARNResource(arn string) (string, bool) {
+	fields := strings.SplitN(arn, ":", 6)
+	if len(fields) != 6 || fields[0] != "arn" || fields[1] == "" || fields[2] != "s3" {
+		return "", false
+	}
+	return fields[5], true
+}
+
+// wildcardMatch reports whether s matches pattern, * matches any sequence of characters and ? matches any
+// single character(case sensitive)
+func wildcardMatch(pattern, s string) bool {
+	p := make(policyPattern, len(pattern))
+	for i := range pattern {
+		p[i] = patternByte{c: pattern[i], wildcard: pattern[i] == '*' || pattern[i] == '?'}
+	}
+	return p.match(s)
+}
+
+// patternByte is a byte of policyPattern, a wildcard(* or ?) or a literal byte
+type patternByte struct {
+	c        byte
+	wildcard bool
+}
+
+// policyPattern is a pattern of a policy value with the policy variables substituted, only * and ? in the
+// policy are wildcards, ${*}, ${?} and the value of a variable are literal
+type policyPattern []patternByte
+
+// appendLiteral appends s to p as literal bytes
+func (p policyPattern) appendLiteral(s string) policyPattern {
+	for i := range s {
+		p = append(p, patternByte{c: s[i]})
+	}
+	return p
+}
+
+// String returns p with wildcards and literal bytes alike
+func (p policyPattern) String() string {
+	b := make([]byte, len(p))
+	for i := range p {
+		b[i] = p[i].c
+	}
+	return string(b)
+}
+
+// toLower returns p with ASCII letters in lower case
+func (p policyPattern) toLower() policyPattern {
+	l := make(policyPattern, len(p))
+	for i, pb := range p {
+		if 'A' <= pb.c && pb.c <= 'Z' {
+			pb.c += 'a' - 'A'
+		}
+		l[i] = pb
+	}
+	return l
+}
+
+// match reports whether s matches p, wildcard * matches any sequence of characters and ? matches any single character
+func (p policyPattern) match(s string) bool {
+	j, i := 0, 0
+	star, next := -1, 0
+	for i < len(s) {
+		if j < len(p) && (p[j].wildcard && p[j].c == '?' || !p[j].wildcard && p[j].c == s[i]) {
+			j++
+			i++
+		} else if j < len(p) && p[j].wildcard && p[j].c == '*' {
+			star, next = j, i
+			j++
+		} else if star >= 0 {
+			j = star + 1
+			next++
+			i = next
+		} else {
+			return false
+		}
+	}
+	for j < len(p) && p[j].wildcard && p[j].c == '*' {
+		j++
+	}
+	return j == len(p)
+}
+
+// readPolicy reads a Bucket policy from stdin(-), a JSON string or a file
+func readPolicy(arg string, stdin io.Reader) ([]byte, error) {
+	if arg == "-" {
+		return io.ReadAll(stdin)
+	}
+	if strings.HasPrefix(strings.TrimSpace(arg), "{") {
+		return []byte(arg), nil
+	}
+	return os.ReadFile(arg)
+}
+
+// policyTemplate is a built-in Bucket policy
+type policyTemplate struct {
+	usage  string
+	render func(bucket, param string) (*policyDocument, error)
+}
+
+// policyTemplates are the built-in Bucket policies
+var policyTemplates = map[string]policyTemplate{
+	"public-read-prefix": {
+		usage: "allow anyone to read Objects with prefix --param(all Objects if empty)",
+		render: func(bucket, prefix string) (*policyDocument, error) {
+			return &policyDocument{
+				Version: policyVersions[0],
+				Statement: policyStatements{{
+					Sid:       "PublicReadPrefix",
+					Effect:    "Allow",
+					Principal: policyPrincipal{"*": {"*"}},
+					Action:    stringOrSlice{"s3:GetObject"},
+					Resource:  stringOrSlice{"arn:aws:s3:::" + bucket + "/" + prefix + "*"},
+				}},
+			}, nil
+		},
+	},
+	"read-only-for-principal": {
+		usage: "allow principal --param(e.g. arn:aws:iam::123456789012:user/name) to list the Bucket and read Objects",
+		render: func(bucket, principal string) (*policyDocument, error) {
+			if principal == "" {
+				return nil, errors.New("template read-only-for-principal requires a principal(--param)")
+			}
+			return &policyDocument{
+				Version: policyVersions[0],
+				Statement: policyStatements{{
+					Sid:       "ReadOnlyForPrincipal",
+					Effect:    "Allow",
+					Principal: policyPrincipal{"AWS": {principal}},
+					Action:    stringOrSlice{"s3:GetBucketLocation", "s3:ListBucket", "s3:GetObject"},
+					Resource:  stringOrSlice{"arn:aws:s3:::" + bucket, "arn:aws:s3:::" + bucket + "/*"},
+				}},
+			}, nil
+		},
+	},
+	"deny-insecure-transport": {
+		usage: "deny all requests not over HTTPS",
+		render: func(bucket, _ string) (*policyDocument, error) {
+			return &policyDocument{
+				Version: policyVersions[0],
+				Statement: policyStatements{{
+					Sid:       "DenyInsecureTransport",
+					Effect:    "Deny",
+					Principal: policyPrincipal{"*": {"*"}},
+					Action:    stringOrSlice{"s3:*"},
+					Resource:  stringOrSlice{"arn:aws:s3:::" + bucket, "arn:aws:s3:::" + bucket + "/*"},
+					Condition: map[string]map[string]stringOrSlice{
+						"Bool": {"aws:SecureTransport": {"false"}},
+					},
+				}},
+			}, nil
+		},
+	},
+}
+
+// policyTemplateUsage returns the names and usages of the built-in Bucket policies
+func policyTemplateUsage() string {
+	names := make([]string, 0, len(policyTemplates))
+	for name := range policyTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "\t%-24s %s\n", name, policyTemplates[name].usage)
+	}
+	return b.String()
+}
+
+// renderPolicy renders the built-in Bucket policy name of bucket in JSON
+func renderPolicy(name, bucket, param string) ([]byte, error) {
+	t, ok := policyTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown policy template %s, available templates:\n%s", name, policyTemplateUsage())
+	}
+	doc, err := t.render(bucket, param)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// checkPolicy parses and validates the Bucket policy of bucket in JSON, returns it in compact JSON
+func checkPolicy(bucket string, policy []byte) (string, error) {
+	doc, err := parsePolicy(policy)
+	if err != nil {
+		return "", err
+	}
+	if err := validatePolicy(doc, bucket); err != nil {
+		return "", fmt.Errorf("invalid policy: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, policy); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// formatPolicy returns the policy in JSON pretty-printed, or in one line if compact
+func formatPolicy(policy []byte, compact bool) string {
+	buf := &bytes.Buffer{}
+	var err error
+	if compact {
+		err = json.Compact(buf, policy)
+	} else {
+		err = json.Indent(buf, policy, "", "  ")
+	}
+	if err != nil {
+		return string(policy)
+	}
+	return buf.String()
+}
+
+// decisions of policy evaluation
+const (
+	policyAllow        = "allow"
+	policyDeny         = "deny"          // denied by a Deny statement
+	policyImplicitDeny = "implicit-deny" // no statement allows the request
+)
+
+// policyRequest is the request to evaluate a Bucket policy against
+type policyRequest struct {
+	Principal string              `json:"principal"`
+	Action    string              `json:"action"`
+	Resource  string              `json:"resource"`
+	Context   map[string][]string `json:"context,omitempty"` // condition key in lower case -> values
+}
+
+// statementResult is the result of a statement of policy evaluation
+type statementResult struct {
+	Statement string `json:"statement"` // Sid or #<index>
+	Effect    string `json:"effect"`
+	Matched   bool   `json:"matched"`
+	Reason    string `json:"reason,omitempty"` // why the statement does not match
+}
+
+// policyDecision is the result of policy evaluation
+type policyDecision struct {
+	Decision   string            `json:"decision"`
+	Statement  string            `json:"statement,omitempty"` // the statement decided the request
+	Request    policyRequest     `json:"request"`
+	Statements []statementResult `json:"statements"`
+}
+
+// evaluatePolicy evaluates doc against req the way S3 evaluates a Bucket policy: a matched Deny
+// statement denies the request, otherwise a matched Allow statement allows it, otherwise it is denied implicitly.
+// Only the Bucket policy is evaluated, IAM policies, ACLs and the Bucket owner are not taken into account.
+func evaluatePolicy(doc *policyDocument, req policyRequest) (*policyDecision, error) {
+	d := &policyDecision{Decision: policyImplicitDeny, Request: req}
+	for i, st := range doc.Statement {
+		r := statementResult{Statement: fmt.Sprintf("#%d", i+1), Effect: st.Effect}
+		if st.Sid != "" {
+			r.Statement = st.Sid
+		}
+		reason, err := matchStatement(&st, req)
+		if err != nil {
+			return nil, fmt.Errorf("Statement %s: %w", r.Statement, err)
+		}
+		r.Matched, r.Reason = reason == "", reason
+		d.Statements = append(d.Statements, r)
+		if !r.Matched {
+			continue
+		}
+		if st.Effect == "Deny" && d.Decision != policyDeny {
+			d.Decision, d.Statement = policyDeny, r.Statement
+		} else if st.Effect == "Allow" && d.Decision == policyImplicitDeny {
+			d.Decision, d.Statement = policyAllow, r.Statement
+		}
+	}
+	return d, nil
+}
+
+// matchStatement returns why st does not apply to req, empty if it applies
+func matchStatement(st *policyStatement, req policyRequest) (string, error) {
+	if len(st.Principal) > 0 && !matchPrincipal(st.Principal, req.Principal) {
+		return "principal not matched", nil
+	}
+	if len(st.NotPrincipal) > 0 && matchPrincipal(st.NotPrincipal, req.Principal) {
+		return "principal matched NotPrincipal", nil
+	}
+	if len(st.Action) > 0 && !matchAny(st.Action, req.Action, true, req.Context) {
+		return "action not matched", nil
+	}
+	if len(st.NotAction) > 0 && matchAny(st.NotAction, req.Action, true, req.Context) {
+		return "action matched NotAction", nil
+	}
+	if len(st.Resource) > 0 && !matchResource(st.Resource, req.Resource, req.Context) {
+		return "resource not matched", nil
+	}
+	if len(st.NotResource) > 0 && matchResource(st.NotResource, req.Resource, req.Context) {
+		return "resource matched NotResource", nil
+	}
+	ops := make([]string, 0, len(st.Condition))
+	for op := range st.Condition {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		keys := make([]string, 0, len(st.Condition[op]))
+		for key := range st.Condition[op] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// condition keys are case insensitive, the keys of req.Context are in lower case
+			ok, err := matchCondition(op, st.Condition[op][key], req.Context[strings.ToLower(key)], req.Context)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				return fmt.Sprintf("condition %s %s not matched", op, key), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// matchPrincipal reports whether principal is one of the principals, an account(123456789012 or
+// arn:aws:iam::123456789012:root) matches all principals of the account
+func matchPrincipal(principals policyPrincipal, principal string) bool {
+	for _, values := range principals {
+		for _, v := range values {
+			if v == "*" || v == principal {
+				return true
+			}
+			account := strings.TrimSuffix(strings.TrimPrefix(v, "arn:aws:iam::"), ":root")
+			if len(account) != 12 || strings.Trim(account, "0123456789") != "" {
+				continue
+			}
+			if principal == account || strings.HasPrefix(principal, "arn:aws:iam::"+account+":") ||
+				strings.HasPrefix(principal, "arn:aws:sts::"+account+":") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny reports whether s matches any of the patterns(with policy variables), actions are case insensitive
+func matchAny(patterns []string, s string, ignoreCase bool, ctx map[string][]string) bool {
+	for _, pattern := range patterns {
+		p := substituteVariables(pattern, ctx)
+		if ignoreCase && p.toLower().match(strings.ToLower(s)) || !ignoreCase && p.match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchResource reports whether S3 ARN resource matches any of the patterns(with policy variables), only the
+// <bucket>[/<key>] of the ARNs are compared, so that any partition(e.g. aws-cn) or Ceph tenant matches
+func matchResource(patterns []string, resource string, ctx map[string][]string) bool {
+	if name, ok := s3ARNResource(resource); ok {
+		resource = name
+	}
+	names := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		names[i] = pattern
+		if name, ok := s3ARNResource(pattern); ok {
+			names[i] = name
+		}
+	}
+	return matchAny(names, resource, false, ctx)
+}
+
+// substituteVariables returns the policyPattern of s, policy variables ${key}(key is case insensitive) are
+// replaced with the value of key in ctx, and ${*}, ${?} and ${$} with literal *, ? and $.
+// A variable of no value is left unchanged so that it does not match.
+func substituteVariables(s string, ctx map[string][]string) policyPattern {
+	var p policyPattern
+	// appendPattern appends s with * and ? as wildcards
+	appendPattern := func(s string) {
+		for i := range s {
+			p = append(p, patternByte{c: s[i], wildcard: s[i] == '*' || s[i] == '?'})
+		}
+	}
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			break
+		}
+		key := s[start+2 : start+end]
+		appendPattern(s[:start])
+		switch values := ctx[strings.ToLower(key)]; {
+		case key == "*" || key == "?" || key == "$":
+			p = p.appendLiteral(key)
+		case len(values) > 0:
+			p = p.appendLiteral(values[0])
+		default:
+			p = p.appendLiteral(s[start : start+end+1])
+		}
+		s = s[start+end+1:]
+	}
+	appendPattern(s)
+	return p
+}
+
+// conditionOperators are the supported condition operators without negation(Not), set(ForAllValues: or
+// ForAnyValue:) and IfExists
+var conditionOperators = []string{
+	"StringEquals", "StringEqualsIgnoreCase", "StringLike",
+	"NumericEquals", "NumericLessThan", "NumericLessThanEquals", "NumericGreaterThan", "NumericGreaterThanEquals",
+	"DateEquals", "DateLessThan", "DateLessThanEquals", "DateGreaterThan", "DateGreaterThanEquals",
+	"Bool", "IpAddress", "ArnEquals", "ArnLike",
+}
+
+// matchCondition evaluates condition operator op(e.g. StringLike, ForAnyValue:StringEquals, DateLessThanIfExists)
+// of policy values against the values of the condition key in the request
+func matchCondition(op string, policyValues, values []string, ctx map[string][]string) (bool, error) {
+	if op == "Null" {
+		if len(policyValues) != 1 || (policyValues[0] != "true" && policyValues[0] != "false") {
+			return false, fmt.Errorf("invalid Null condition value %v(true or false)", policyValues)
+		}
+		return (len(values) == 0) == (policyValues[0] == "true"), nil
+	}
+	set, name, _ := strings.Cut(op, ":")
+	if name == "" {
+		set, name = "", op
+	}
+	name, ifExists := strings.CutSuffix(name, "IfExists")
+	negated := strings.Contains(name, "Not")
+	base := strings.Replace(name, "Not", "", 1)
+	if !slices.Contains(conditionOperators, base) || (set != "" && set != "ForAllValues" && set != "ForAnyValue") {
+		return false, fmt.Errorf("unsupported condition operator %s", op)
+	}
+	if len(values) == 0 {
+		switch {
+		case ifExists || set == "ForAllValues":
+			return true, nil
+		case set == "ForAnyValue":
+			return false, nil
+		default:
+			return negated, nil
+		}
+	}
+	// matches reports whether a request value matches any of the policy values
+	matches := func(v string) (bool, error) {
+		for _, pv := range policyValues {
+			ok, err := compareCondition(base, substituteVariables(pv, ctx), v)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	switch set {
+	case "ForAllValues":
+		for _, v := range values {
+			ok, err := matches(v)
+			if err != nil {
+				return false, err
+			}
+			if ok == negated {
+				return false, nil
+			}
+		}
+		return true, nil
+	default: // ForAnyValue or single valued
+		for _, v := range values {
+			ok, err := matches(v)
+			if err != nil {
+				return false, err
+			}
+			if ok != negated {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// compareCondition reports whether request value v matches policy value p with condition operator op of no negation,
+// p is a pattern of StringLike and ArnLike, or a literal value of the others
+func compareCondition(op string, p policyPattern, v string) (bool, error) {
+	if op == "StringLike" || op == "ArnLike" {
+		return p.match(v), nil
+	}
+	pv := p.String()
+	switch op {
+	case "StringEquals", "ArnEquals":
+		return pv == v, nil
+	case "StringEqualsIgnoreCase":
+		return strings.EqualFold(pv, v), nil
+	case "Bool":
+		return strings.EqualFold(pv, v), nil
+	case "IpAddress":
+		_, network, err := net.ParseCIDR(pv)
+		if err != nil {
+			ip := net.ParseIP(pv)
+			if ip == nil {
+				return false, fmt.Errorf("invalid IP address %s", pv)
+			}
+			return ip.Equal(net.ParseIP(v)), nil
+		}
+		ip := net.ParseIP(v)
+		return ip != nil && network.Contains(ip), nil
+	case "NumericEquals", "NumericLessThan", "NumericLessThanEquals", "NumericGreaterThan", "NumericGreaterThanEquals":
+		a, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return false, nil
+		}
+		b, err := strconv.ParseFloat(pv, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number %s", pv)
+		}
+		return compareOrdered(strings.TrimPrefix(op, "Numeric"), a, b), nil
+	case "DateEquals", "DateLessThan", "DateLessThanEquals", "DateGreaterThan", "DateGreaterThanEquals":
+		a, err := parseConditionDate(v)
+		if err != nil {
+			return false, nil
+		}
+		b, err := parseConditionDate(pv)
+		if err != nil {
+			return false, fmt.Errorf("invalid date %s", pv)
+		}
+		return compareOrdered(strings.TrimPrefix(op, "Date"), a.Unix(), b.Unix()), nil
+	}
+	return false, fmt.Errorf("unsupported condition operator %s", op)
+}
+
+// compareOrdered compares a with b by Equals, LessThan, LessThanEquals, GreaterThan or GreaterThanEquals
+func compareOrdered[T int64 | float64](op string, a, b T) bool {
+	switch op {
+	case "Equals":
+		return a == b
+	case "LessThan":
+		return a < b
+	case "LessThanEquals":
+		return a <= b
+	case "GreaterThan":
+		return a > b
+	case "GreaterThanEquals":
+		return a >= b
+	}
+	return false
+}
+
+// parseConditionDate parses a date of condition in RFC3339, 2006-01-02 or epoch seconds
+func parseConditionDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// policyResource returns the ARN of bucket, or of Object bucket/key
+func policyResource(bucket, key string) string {
+	if key == "" {
+		return "arn:aws:s3:::" + bucket
+	}
+	return "arn:aws:s3:::" + bucket + "/" + key
+}
+
+// policyCheck evaluates the Bucket policy of bucket(or in file if not empty) against req and prints
+// the decision and the statement decided it
+func (sc *S3Cli) policyCheck(ctx context.Context, bucket, file string, req policyRequest) error {
+	var policy []byte
+	if file != "" {
+		var err error
+		if policy, err = readPolicy(file, os.Stdin); err != nil {
+			return err
+		}
+	} else {
+		p, err := sc.bucketPolicy(ctx, bucket)
+		if err != nil {
+			return fmt.Errorf("get bucket policy %s failed: %w", bucket, err)
+		}
+		policy = []byte(p)
+	}
+	doc, err := parsePolicy(policy)
+	if err != nil {
+		return err
+	}
+	if err := validatePolicy(doc, ""); err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+	d, err := evaluatePolicy(doc, req)
+	if err != nil {
+		return err
+	}
+
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			fmt.Println(d)
+			return nil
+		}
+		fmt.Printf("%s", jo)
+		return nil
+	}
+	statement := d.Statement
+	if sc.lineOutput() {
+		if statement == "" {
+			statement = "-"
+		}
+		fmt.Printf("%s\t%s\n", d.Decision, statement)
+		return nil
+	}
+	if statement == "" {
+		statement = "(no statement allows the request)"
+	}
+	fmt.Printf("Decision  : %s\n", d.Decision)
+	fmt.Printf("Statement : %s\n", statement)
+	if sc.verboseOutput() {
+		fmt.Printf("Principal : %s\n", req.Principal)
+		fmt.Printf("Action    : %s\n", req.Action)
+		fmt.Printf("Resource  : %s\n", req.Resource)
+		for _, r := range d.Statements {
+			result := "matched"
+			if !r.Matched {
+				result = r.Reason
+			}
+			fmt.Printf("  %-20s %-5s %s\n", r.Statement, r.Effect, result)
+		}
+	}
+	return nil
+}
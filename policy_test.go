@@ -0,0 +1,332 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parsePolicy(t *testing.T) {
+	doc, err := parsePolicy([]byte(`{"Version": "2012-10-17", "Statement": {"Effect": "Deny", "Principal": "*", "Action": "s3:*",
+		"Resource": ["arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"], "Condition": {"Bool": {"aws:SecureTransport": false}}}}`))
+	if err != nil {
+		t.Fatalf("parsePolicy failed: %s", err)
+	}
+	st := doc.Statement[0]
+	if len(doc.Statement) != 1 || !reflect.DeepEqual(st.Principal, policyPrincipal{"*": {"*"}}) || !reflect.DeepEqual(st.Action, stringOrSlice{"s3:*"}) ||
+		len(st.Resource) != 2 || !reflect.DeepEqual(st.Condition["Bool"]["aws:SecureTransport"], stringOrSlice{"false"}) {
+		t.Errorf("parsePolicy unexpected policy: %+v", doc)
+	}
+	for _, v := range []string{
+		`{"Version": "2012-10-17", "Statment": []}`,
+		`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principals": "*"}]}`,
+		`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "anyone"}]}`,
+		`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": {"s3": "*"}}]}`,
+	} {
+		if _, err := parsePolicy([]byte(v)); err == nil {
+			t.Errorf("parsePolicy(%s) expect error", v)
+		}
+	}
+}
+
+func Test_validatePolicy(t *testing.T) {
+	statement := `"Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::123456789012:root"]}, "Action": ["s3:GetObject"], "Resource": "arn:aws:s3:::bucket/*"`
+	cases := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{"valid", `{"Version": "2012-10-17", "Statement": [{` + statement + `}]}`, ""},
+		{"wildcard bucket", `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "NotPrincipal": {"AWS": "arn:aws:iam::1:root"}, "NotAction": "s3:Get*", "Resource": "arn:aws:s3:::buck*"}]}`, ""},
+		{"version", `{"Version": "2020-01-01", "Statement": [{` + statement + `}]}`, "invalid Version"},
+		{"no version", `{"Statement": [{` + statement + `}]}`, "invalid Version"},
+		{"no statement", `{"Version": "2012-10-17", "Statement": []}`, "no Statement"},
+		{"duplicate sid", `{"Version": "2012-10-17", "Statement": [{"Sid": "a", ` + statement + `}, {"Sid": "a", ` + statement + `}]}`, "duplicate Sid"},
+		{"effect", `{"Version": "2012-10-17", "Statement": [{"Effect": "allow", "Principal": "*", "Action": "s3:*", "Resource": "*"}]}`, "Statement #1: invalid Effect"},
+		{"no principal", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}`, "Principal"},
+		{"no action", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Resource": "*"}]}`, "Action"},
+		{"action", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "GetObject", "Resource": "*"}]}`, "invalid Action"},
+		{"no resource", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*"}]}`, "Resource"},
+		{"resource", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "bucket/*"}]}`, "invalid Resource"},
+		{"other bucket", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3:::other/*"}]}`, "not in Bucket"},
+		{"action case", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "S3:GetObject", "Resource": "arn:aws:s3:::bucket/*"}]}`, ""},
+		{"partition", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws-cn:s3:::bucket/*"}]}`, ""},
+		{"ceph tenant", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": ["arn:aws:s3::tenant:bucket", "arn:aws:s3::tenant:bucket/*"]}]}`, ""},
+		{"ceph other bucket", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:s3::tenant:other/*"}]}`, "not in Bucket"},
+		{"not s3", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "arn:aws:iam::123456789012:user/name"}]}`, "invalid Resource"},
+		{"no partition", `{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:*", "Resource": "arn::s3:::bucket"}]}`, "invalid Resource"},
+	}
+	for _, c := range cases {
+		_, err := checkPolicy("bucket", []byte(c.policy))
+		if c.err == "" && err != nil {
+			t.Errorf("checkPolicy %s failed: %s", c.name, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("checkPolicy %s expect error %s, got: %v", c.name, c.err, err)
+		}
+	}
+}
+
+func Test_wildcardMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"s3:Get*", "s3:GetObject", true},
+		{"s3:Get*", "s3:PutObject", false},
+		{"arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket/a/b", true},
+		{"arn:aws:s3:::bucket/*", "arn:aws:s3:::bucket", false},
+		{"arn:aws:s3:::bucket/*.log", "arn:aws:s3:::bucket/a/b.log", true},
+		{"arn:aws:s3:::bucket/*.log", "arn:aws:s3:::bucket/a.logs", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"a*b*c", "aXbYbZc", true},
+		{"ABC", "abc", false},
+	}
+	for _, c := range cases {
+		if got := wildcardMatch(c.pattern, c.s); got != c.match {
+			t.Errorf("wildcardMatch(%s, %s) expect: %v, got: %v", c.pattern, c.s, c.match, got)
+		}
+	}
+}
+
+func Test_readPolicy(t *testing.T) {
+	policy := `{"Version": "2012-10-17"}`
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(filename, []byte(policy), 0644)
+	for _, arg := range []string{policy, filename, "-"} {
+		got, err := readPolicy(arg, strings.NewReader(policy))
+		if err != nil || string(got) != policy {
+			t.Errorf("readPolicy(%s) expect: %s, got: %s, %v", arg, policy, got, err)
+		}
+	}
+	if _, err := readPolicy(filepath.Join(t.TempDir(), "none.json"), nil); err == nil {
+		t.Errorf("readPolicy expect error of not existing file")
+	}
+}
+
+func Test_renderPolicy(t *testing.T) {
+	cases := []struct {
+		name  string
+		param string
+		want  string
+	}{
+		{"public-read-prefix", "public/", `{"Version":"2012-10-17","Statement":[{"Sid":"PublicReadPrefix","Effect":"Allow","Principal":"*",` +
+			`"Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/public/*"}]}`},
+		{"public-read-prefix", "", `{"Version":"2012-10-17","Statement":[{"Sid":"PublicReadPrefix","Effect":"Allow","Principal":"*",` +
+			`"Action":"s3:GetObject","Resource":"arn:aws:s3:::bucket/*"}]}`},
+		{"read-only-for-principal", "arn:aws:iam::123456789012:user/name", `{"Version":"2012-10-17","Statement":[{"Sid":"ReadOnlyForPrincipal","Effect":"Allow",` +
+			`"Principal":{"AWS":"arn:aws:iam::123456789012:user/name"},"Action":["s3:GetBucketLocation","s3:ListBucket","s3:GetObject"],` +
+			`"Resource":["arn:aws:s3:::bucket","arn:aws:s3:::bucket/*"]}]}`},
+		{"deny-insecure-transport", "", `{"Version":"2012-10-17","Statement":[{"Sid":"DenyInsecureTransport","Effect":"Deny","Principal":"*",` +
+			`"Action":"s3:*","Resource":["arn:aws:s3:::bucket","arn:aws:s3:::bucket/*"],"Condition":{"Bool":{"aws:SecureTransport":"false"}}}]}`},
+	}
+	rendered := map[string]bool{}
+	for _, c := range cases {
+		policy, err := renderPolicy(c.name, "bucket", c.param)
+		if err != nil {
+			t.Errorf("renderPolicy(%s, %s) failed: %s", c.name, c.param, err)
+			continue
+		}
+		got, err := checkPolicy("bucket", policy)
+		if err != nil {
+			t.Errorf("renderPolicy(%s, %s) invalid policy: %s", c.name, c.param, err)
+		}
+		if got != c.want {
+			t.Errorf("renderPolicy(%s, %s) expect: %s, got: %s", c.name, c.param, c.want, got)
+		}
+		rendered[c.name] = true
+	}
+	for name := range policyTemplates {
+		if !rendered[name] {
+			t.Errorf("renderPolicy(%s) is not tested", name)
+		}
+	}
+	if _, err := renderPolicy("read-only-for-principal", "bucket", ""); err == nil {
+		t.Errorf("renderPolicy(read-only-for-principal) expect error without principal")
+	}
+	if _, err := renderPolicy("public-write", "bucket", ""); err == nil {
+		t.Errorf("renderPolicy(public-write) expect error")
+	}
+}
+
+func Test_evaluatePolicy(t *testing.T) {
+	doc, err := parsePolicy([]byte(`{"Version": "2012-10-17", "Statement": [
+		{"Sid": "PublicRead", "Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/public/*"},
+		{"Sid": "AccountAll", "Effect": "Allow", "Principal": {"AWS": "123456789012"}, "Action": "s3:*", "Resource": ["arn:aws:s3:::bucket", "arn:aws:s3:::bucket/*"]},
+		{"Sid": "HomeDir", "Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::210987654321:user/bob"}, "Action": ["s3:Get*", "s3:Put*"],
+			"Resource": "arn:aws:s3:::bucket/home/${aws:username}/*"},
+		{"Sid": "DenyDelete", "Effect": "Deny", "NotPrincipal": {"AWS": "arn:aws:iam::123456789012:user/admin"}, "Action": "s3:Delete*",
+			"NotResource": "arn:aws:s3:::bucket/tmp/*"},
+		{"Sid": "DenyOutside", "Effect": "Deny", "Principal": "*", "NotAction": "s3:GetObject", "Resource": "arn:aws:s3:::bucket/*",
+			"Condition": {"NotIpAddressIfExists": {"aws:SourceIp": ["10.0.0.0/8", "192.168.1.1"]}}}
+	]}`))
+	if err != nil {
+		t.Fatalf("parsePolicy failed: %s", err)
+	}
+	cases := []struct {
+		name      string
+		principal string
+		action    string
+		key       string
+		context   map[string][]string
+		decision  string
+		statement string
+	}{
+		{"anonymous read public", "*", "s3:GetObject", "public/a.txt", nil, policyAllow, "PublicRead"},
+		{"action case insensitive", "*", "s3:getobject", "public/a.txt", nil, policyAllow, "PublicRead"},
+		{"anonymous read private", "*", "s3:GetObject", "private/a.txt", nil, policyImplicitDeny, ""},
+		{"anonymous put public", "*", "s3:PutObject", "public/a.txt", nil, policyImplicitDeny, ""},
+		{"account user list", "arn:aws:iam::123456789012:user/alice", "s3:ListBucket", "", nil, policyAllow, "AccountAll"},
+		{"account user delete", "arn:aws:iam::123456789012:user/alice", "s3:DeleteObject", "a.txt", nil, policyDeny, "DenyDelete"},
+		{"admin delete", "arn:aws:iam::123456789012:user/admin", "s3:DeleteObject", "a.txt", nil, policyAllow, "AccountAll"},
+		{"account user delete tmp", "arn:aws:iam::123456789012:user/alice", "s3:DeleteObject", "tmp/a.txt", nil, policyAllow, "AccountAll"},
+		{"home dir", "arn:aws:iam::210987654321:user/bob", "s3:PutObject", "home/bob/a.txt", map[string][]string{"aws:username": {"bob"}}, policyAllow, "HomeDir"},
+		{"other home dir", "arn:aws:iam::210987654321:user/bob", "s3:PutObject", "home/eve/a.txt", map[string][]string{"aws:username": {"bob"}}, policyImplicitDeny, ""},
+		{"home dir no username", "arn:aws:iam::210987654321:user/bob", "s3:PutObject", "home/bob/a.txt", nil, policyImplicitDeny, ""},
+		{"inside ip", "arn:aws:iam::123456789012:user/alice", "s3:PutObject", "a.txt", map[string][]string{"aws:SourceIp": {"10.1.2.3"}}, policyAllow, "AccountAll"},
+		{"ip", "arn:aws:iam::123456789012:user/alice", "s3:PutObject", "a.txt", map[string][]string{"aws:SourceIp": {"192.168.1.1"}}, policyAllow, "AccountAll"},
+		{"outside ip", "arn:aws:iam::123456789012:user/alice", "s3:PutObject", "a.txt", map[string][]string{"aws:SourceIp": {"172.16.0.1"}}, policyDeny, "DenyOutside"},
+		{"outside ip read", "*", "s3:GetObject", "public/a.txt", map[string][]string{"aws:SourceIp": {"172.16.0.1"}}, policyAllow, "PublicRead"},
+	}
+	for _, c := range cases {
+		// requests are from inside the network unless aws:SourceIp is set
+		ctx := map[string][]string{"aws:sourceip": {"10.0.0.1"}}
+		for k, v := range c.context {
+			ctx[strings.ToLower(k)] = v
+		}
+		d, err := evaluatePolicy(doc, policyRequest{Principal: c.principal, Action: c.action, Resource: policyResource("bucket", c.key), Context: ctx})
+		if err != nil {
+			t.Errorf("evaluatePolicy %s failed: %s", c.name, err)
+			continue
+		}
+		if d.Decision != c.decision || d.Statement != c.statement {
+			t.Errorf("evaluatePolicy %s expect: %s %s, got: %s %s", c.name, c.decision, c.statement, d.Decision, d.Statement)
+		}
+	}
+
+	// condition keys and policy variables are case insensitive, the request keys are in lower case
+	caseDoc, err := parsePolicy([]byte(`{"Version": "2012-10-17", "Statement": [{"Sid": "Referer", "Effect": "Allow", "Principal": "*", "Action": "s3:GetObject",
+		"Resource": "arn:aws:s3:::bucket/${AWS:UserName}/*", "Condition": {"StringLike": {"AWS:REFERER": "https://example.com/*"}}}]}`))
+	if err != nil {
+		t.Fatalf("parsePolicy failed: %s", err)
+	}
+	req := policyRequest{Principal: "*", Action: "s3:GetObject", Resource: policyResource("bucket", "bob/a"),
+		Context: map[string][]string{"aws:referer": {"https://example.com/a"}, "aws:username": {"bob"}}}
+	if d, err := evaluatePolicy(caseDoc, req); err != nil || d.Decision != policyAllow {
+		t.Errorf("evaluatePolicy of condition key in upper case expect: %s, got: %+v, %v", policyAllow, d, err)
+	}
+
+	// the partition and Ceph tenant of Resource ARNs are not compared
+	arnDoc, err := parsePolicy([]byte(`{"Version": "2012-10-17", "Statement": [
+		{"Sid": "China", "Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws-cn:s3:::bucket/cn/*"},
+		{"Sid": "Tenant", "Effect": "Allow", "Principal": "*", "Action": "s3:ListBucket", "Resource": "arn:aws:s3::tenant:bucket"},
+		{"Sid": "DenyTenant", "Effect": "Deny", "Principal": "*", "Action": "s3:GetObject", "NotResource": "arn:aws:s3::tenant:bucket/cn/*"}
+	]}`))
+	if err != nil {
+		t.Fatalf("parsePolicy failed: %s", err)
+	}
+	for key, want := range map[string][2]string{
+		"cn/a":    {"s3:GetObject", policyAllow},
+		"other/a": {"s3:GetObject", policyDeny},
+		"":        {"s3:ListBucket", policyAllow},
+	} {
+		d, err := evaluatePolicy(arnDoc, policyRequest{Principal: "*", Action: want[0], Resource: policyResource("bucket", key)})
+		if err != nil || d.Decision != want[1] {
+			t.Errorf("evaluatePolicy %s %s expect: %s, got: %+v, %v", want[0], key, want[1], d, err)
+		}
+	}
+
+	doc.Statement[0].Condition = map[string]map[string]stringOrSlice{"StringMatches": {"aws:Referer": {"x"}}}
+	if _, err := evaluatePolicy(doc, policyRequest{Principal: "*", Action: "s3:GetObject", Resource: policyResource("bucket", "public/a")}); err == nil {
+		t.Errorf("evaluatePolicy expect error of unsupported condition operator")
+	}
+}
+
+func Test_matchCondition(t *testing.T) {
+	cases := []struct {
+		op     string
+		policy []string
+		values []string
+		match  bool
+	}{
+		{"StringEquals", []string{"a", "b"}, []string{"b"}, true},
+		{"StringEquals", []string{"a"}, []string{"A"}, false},
+		{"StringEquals", []string{"a"}, nil, false},
+		{"StringEqualsIgnoreCase", []string{"a"}, []string{"A"}, true},
+		{"StringNotEquals", []string{"a", "b"}, []string{"c"}, true},
+		{"StringNotEquals", []string{"a", "b"}, []string{"a"}, false},
+		{"StringNotEquals", []string{"a"}, nil, true},
+		{"StringLike", []string{"home/*"}, []string{"home/bob/"}, true},
+		{"StringNotLike", []string{"home/*"}, []string{"tmp/"}, true},
+		{"StringEqualsIfExists", []string{"a"}, nil, true},
+		{"StringEqualsIfExists", []string{"a"}, []string{"b"}, false},
+		{"ForAllValues:StringEquals", []string{"a", "b"}, []string{"a", "b"}, true},
+		{"ForAllValues:StringEquals", []string{"a", "b"}, []string{"a", "c"}, false},
+		{"ForAllValues:StringEquals", []string{"a"}, nil, true},
+		{"ForAnyValue:StringEquals", []string{"a"}, []string{"c", "a"}, true},
+		{"ForAnyValue:StringEquals", []string{"a"}, []string{"c"}, false},
+		{"ForAnyValue:StringEquals", []string{"a"}, nil, false},
+		{"ForAllValues:StringNotLike", []string{"x*"}, []string{"a", "b"}, true},
+		{"ForAllValues:StringNotLike", []string{"x*"}, []string{"a", "xb"}, false},
+		{"Bool", []string{"true"}, []string{"true"}, true},
+		{"Bool", []string{"false"}, []string{"true"}, false},
+		{"IpAddress", []string{"10.0.0.0/8"}, []string{"10.2.3.4"}, true},
+		{"IpAddress", []string{"2001:db8::/32"}, []string{"2001:db8::1"}, true},
+		{"NotIpAddress", []string{"10.0.0.0/8"}, []string{"11.0.0.1"}, true},
+		{"NumericLessThan", []string{"10"}, []string{"9"}, true},
+		{"NumericGreaterThanEquals", []string{"10"}, []string{"9"}, false},
+		{"NumericNotEquals", []string{"10"}, []string{"9"}, true},
+		{"DateLessThan", []string{"2030-01-01T00:00:00Z"}, []string{"2029-12-31T23:59:59Z"}, true},
+		{"DateGreaterThan", []string{"2030-01-01"}, []string{"1893456000"}, false},
+		{"ArnLike", []string{"arn:aws:iam::*:role/admin"}, []string{"arn:aws:iam::123456789012:role/admin"}, true},
+		{"Null", []string{"true"}, nil, true},
+		{"Null", []string{"false"}, nil, false},
+		{"Null", []string{"false"}, []string{"a"}, true},
+	}
+	for _, c := range cases {
+		got, err := matchCondition(c.op, c.policy, c.values, nil)
+		if err != nil || got != c.match {
+			t.Errorf("matchCondition(%s, %v, %v) expect: %v, got: %v, %v", c.op, c.policy, c.values, c.match, got, err)
+		}
+	}
+	for _, op := range []string{"StringMatches", "AllValues:StringEquals", "IpAddress", "NumericEquals"} {
+		if _, err := matchCondition(op, []string{"x"}, []string{"1"}, nil); err == nil {
+			t.Errorf("matchCondition(%s) expect error", op)
+		}
+	}
+}
+
+func Test_substituteVariables(t *testing.T) {
+	ctx := map[string][]string{"aws:username": {"bob"}, "aws:userid": {"a*b?"}}
+	cases := []struct {
+		pattern string
+		want    string // String of the policyPattern
+		s       string
+		match   bool
+	}{
+		{"home/${aws:username}/*", "home/bob/*", "home/bob/a.txt", true},
+		{"home/${AWS:UserName}/*", "home/bob/*", "home/bob/a.txt", true},
+		{"home/${aws:principaltype}/*", "home/${aws:principaltype}/*", "home/${aws:principaltype}/a", true},
+		{"home/${aws:principaltype}/*", "home/${aws:principaltype}/*", "home/x/a", false},
+		{"literal/${*}/${?}/${$}", "literal/*/?/$", "literal/*/?/$", true},
+		{"literal/${*}/${?}/${$}", "literal/*/?/$", "literal/a/b/$", false},
+		{"id/${aws:userid}", "id/a*b?", "id/a*b?", true},
+		{"id/${aws:userid}", "id/a*b?", "id/aXXbY", false},
+		{"${aws:username}${aws:username", "bob${aws:username", "bob${aws:username", true},
+	}
+	for _, c := range cases {
+		p := substituteVariables(c.pattern, ctx)
+		if got := p.String(); got != c.want {
+			t.Errorf("substituteVariables(%s) expect: %s, got: %s", c.pattern, c.want, got)
+		}
+		if got := p.match(c.s); got != c.match {
+			t.Errorf("substituteVariables(%s) match %s expect: %v, got: %v", c.pattern, c.s, c.match, got)
+		}
+	}
+}
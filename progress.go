@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	progressBarWidth = 30
+	progressInterval = time.Second // interval of progress lines, the TTY bar is refreshed 5 times faster
+)
+
+// progress reports the bytes transferred, rate and ETA of a transfer.
+// All request and response bodies of the requests with the progress in their context are counted,
+// so concurrent parts and Objects of the transfer share one progress.
+type progress struct {
+	name   string
+	total  int64 // atomic, unknown if < 0
+	done   int64 // atomic
+	start  time.Time
+	output string // outputLine, outputJson or "tty"(progress bar), others are plain lines
+	w      io.Writer
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type progressKey struct{}
+
+// startProgress starts the progress(total bytes, unknown if < 0) of transfer name if --progress is set,
+// it returns the context to send requests of the transfer with, the progress and the function to stop it.
+// The progress is nil if it is disabled or the transfer is part of a transfer with progress(e.g. downloadPrefix).
+func (sc *S3Cli) startProgress(ctx context.Context, name string, total int64) (context.Context, *progress, func()) {
+	if !sc.progress || ctx.Value(progressKey{}) != nil {
+		return ctx, nil, func() {}
+	}
+	p := &progress{
+		name:   name,
+		total:  total,
+		start:  time.Now(),
+		output: outputSimple,
+		w:      os.Stderr,
+		stop:   make(chan struct{}),
+	}
+	interval := progressInterval
+	if sc.jsonOutput() {
+		p.output = outputJson
+	} else if sc.lineOutput() {
+		p.output = outputLine
+	} else if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		p.output = "tty"
+		interval /= 5
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render(false)
+			case <-p.stop:
+				p.render(true)
+				return
+			}
+		}
+	}()
+	return context.WithValue(ctx, progressKey{}, p), p, func() {
+		close(p.stop)
+		p.wg.Wait()
+	}
+}
+
+// progressFrom returns the progress of ctx, or nil
+func progressFrom(ctx context.Context) *progress {
+	p, _ := ctx.Value(progressKey{}).(*progress)
+	return p
+}
+
+// setTotal sets the total bytes of the transfer if it was unknown
+func (p *progress) setTotal(total int64) {
+	if p != nil {
+		atomic.CompareAndSwapInt64(&p.total, -1, total)
+	}
+}
+
+// add counts n bytes transferred
+func (p *progress) add(n int64) {
+	if p != nil {
+		atomic.AddInt64(&p.done, n)
+	}
+}
+
+func (p *progress) render(final bool) {
+	done := atomic.LoadInt64(&p.done)
+	total := atomic.LoadInt64(&p.total)
+	elapsed := time.Since(p.start)
+	rate := float64(done) / max(elapsed.Seconds(), 0.001)
+	var eta time.Duration
+	if total >= 0 && rate > 0 && done < total {
+		eta = time.Duration(float64(total-done) / rate * float64(time.Second)).Round(time.Second)
+	}
+
+	switch p.output {
+	case "tty":
+		bar := strings.Repeat(" ", progressBarWidth)
+		percent := "  ?%"
+		if total > 0 {
+			filled := int(min(done, total) * progressBarWidth / total)
+			bar = strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+			percent = fmt.Sprintf("%3d%%", min(done, total)*100/total)
+		}
+		line := fmt.Sprintf("\r%s %s [%s] %s/%s %s/s ETA %s", p.name, percent, bar, formatBytes(done), formatBytes(total), formatBytes(int64(rate)), eta)
+		if final {
+			line += "\n"
+		}
+		fmt.Fprint(p.w, line)
+	case outputJson:
+		jo, err := json.Marshal(map[string]interface{}{
+			"name":  p.name,
+			"bytes": done,
+			"total": total,
+			"rate":  int64(rate),
+			"eta":   eta.Seconds(),
+			"done":  final,
+		})
+		if err == nil {
+			fmt.Fprintf(p.w, "%s\n", jo)
+		}
+	default:
+		fmt.Fprintln(p.w, time.Now().Format(time.RFC3339), "progress", p.name, done, total, int64(rate), eta.Seconds())
+	}
+}
+
+// formatBytes formats n bytes as B, KiB, MiB, GiB or TiB, "?" if n < 0(unknown)
+func formatBytes(n int64) string {
+	if n < 0 {
+		return "?"
+	}
+	if n < 1<<10 {
+		return fmt.Sprintf("%dB", n)
+	}
+	v := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		v /= 1 << 10
+		if v < 1<<10 || unit == "TiB" {
+			return fmt.Sprintf("%.1f%s", v, unit)
+		}
+	}
+	return ""
+}
+
+// progressReadCloser counts the bytes read to progress p
+type progressReadCloser struct {
+	io.ReadCloser
+	p *progress
+}
+
+func (r *progressReadCloser) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.p.add(int64(n))
+	return n, err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_formatBytes(t *testing.T) {
+	cases := map[int64]string{
+		-1:             "?",
+		0:              "0B",
+		1023:           "1023B",
+		1536:           "1.5KiB",
+		50 << 20:       "50.0MiB",
+		3 << 29:        "1.5GiB",
+		(1 << 50) * 2:  "2048.0TiB",
+		(1 << 40) + 10: "1.0TiB",
+	}
+	for n, want := range cases {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) expect: %s, got: %s", n, want, got)
+		}
+	}
+}
+
+func Test_startProgress(t *testing.T) {
+	sc := s3cliTest
+	sc.progress = true
+	sc.output = outputLine
+	ctx, p, finish := sc.startProgress(context.Background(), "download", -1)
+	if p == nil {
+		t.Fatalf("startProgress expect a progress")
+	}
+	// nested transfers count to the outer progress
+	if _, nested, _ := sc.startProgress(ctx, "nested", -1); nested != nil {
+		t.Errorf("startProgress expect nil progress of nested transfer")
+	}
+
+	filename := filepath.Join(t.TempDir(), testObjectKey)
+	if err := sc.getObject(ctx, testBucketName, testObjectKey, "", "", filename, downloadOptions{}); err != nil {
+		t.Fatalf("getObject failed: %s", err)
+	}
+	finish()
+	size := int64(len(testObjectContent))
+	if done, total := atomic.LoadInt64(&p.done), atomic.LoadInt64(&p.total); done != size || total != size {
+		t.Errorf("download progress expect %d/%d bytes, got: %d/%d", size, size, done, total)
+	}
+
+	ctx, p, finish = sc.startProgress(context.Background(), "upload", -1)
+	fd, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", filename, err)
+	}
+	defer fd.Close()
+	if _, _, err := sc.sendFile(ctx, testBucketName, "startProgress", "", nil, false, fd, mpuOptions{threshold: 5 << 20}); err != nil {
+		t.Fatalf("sendFile failed: %s", err)
+	}
+	finish()
+	if done := atomic.LoadInt64(&p.done); done != size {
+		t.Errorf("upload progress expect %d bytes, got: %d", size, done)
+	}
+
+	var buf bytes.Buffer
+	p.w = &buf
+	p.render(true)
+	if !bytes.Contains(buf.Bytes(), []byte("progress upload")) {
+		t.Errorf("progress line expect 'progress upload', got: %s", buf.String())
+	}
+}
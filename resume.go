@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// readJSONFile decodes the JSON file name to v
+func readJSONFile(name string, v interface{}) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSONFile replaces the file name with v encoded as JSON, the file is always complete
+func writeJSONFile(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, true, func(fd *os.File) error {
+		_, err := fd.Write(data)
+		return err
+	})
+}
+
+// downloadState is the sidecar state file(<file>.part.json) of a partial download(<file>.part),
+// a partial download is resumed only if the Object is still the same.
+type downloadState struct {
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	VersionID    string    `json:"versionId,omitempty"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"`
+	Size         int64     `json:"size"`
+}
+
+func (s downloadState) same(o downloadState) bool {
+	return s.Bucket == o.Bucket && s.Key == o.Key && s.VersionID == o.VersionID &&
+		s.ETag == o.ETag && s.LastModified.Equal(o.LastModified) && s.Size == o.Size
+}
+
+// headDownloadState returns the current downloadState of Object bucket/key
+func (sc *S3Cli) headDownloadState(ctx context.Context, bucket, key, version string) (downloadState, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.HeadObjectRequest(input)
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return downloadState{}, fmt.Errorf("head object %s failed: %w", key, err)
+	}
+	return downloadState{
+		Bucket:       bucket,
+		Key:          key,
+		VersionID:    version,
+		ETag:         aws.StringValue(resp.ETag),
+		LastModified: aws.TimeValue(resp.LastModified),
+		Size:         aws.Int64Value(resp.ContentLength),
+	}, nil
+}
+
+// getObjectResume download a Object to filename+".part" and renames it to filename when complete.
+// If the partial file and its state file are left by an interrupted download of the same Object
+// (same ETag, Last-Modified and size), only the missing tail is fetched, otherwise it starts over.
+func (sc *S3Cli) getObjectResume(ctx context.Context, bucket, key, version, filename string, opt downloadOptions) error {
+	partFile := filename + ".part"
+	stateFile := partFile + ".json"
+
+	remote, err := sc.headDownloadState(ctx, bucket, key, version)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	var local downloadState
+	if err := readJSONFile(stateFile, &local); err == nil && local.same(remote) {
+		if info, err := os.Stat(partFile); err == nil && info.Size() <= remote.Size {
+			offset = info.Size()
+		}
+	}
+
+	p := progressFrom(ctx)
+	p.setTotal(remote.Size)
+	p.add(offset)
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	fd, err := os.OpenFile(partFile, flag, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	// the state is written after the stale partial file is truncated
+	if offset == 0 {
+		if err := writeJSONFile(stateFile, remote); err != nil {
+			return err
+		}
+	}
+
+	if offset < remote.Size {
+		input := &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-", offset)),
+		}
+		if version != "" {
+			input.VersionId = aws.String(version)
+		}
+		if remote.ETag != "" {
+			input.IfMatch = aws.String(remote.ETag)
+		}
+		req, resp := sc.Client.GetObjectRequest(input)
+		req.SetContext(ctx)
+		sc.addCustomHeader(req.HTTPRequest)
+		if err := req.Send(); err != nil {
+			return fmt.Errorf("get object %s failed: %w", key, err)
+		}
+		defer resp.Body.Close()
+		if _, err := io.Copy(fd, resp.Body); err != nil {
+			return fmt.Errorf("download %s interrupted at %s(run again with --continue to resume): %w", key, partFile, err)
+		}
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(partFile)
+	if err != nil {
+		return err
+	}
+	if info.Size() != remote.Size {
+		return fmt.Errorf("download %s: expect %d bytes, got %d bytes in %s", key, remote.Size, info.Size(), partFile)
+	}
+	if opt.checksum {
+		algorithm, checksum, err := sc.headChecksum(ctx, bucket, key, version)
+		if err != nil {
+			return err
+		}
+		if algorithm, checksum = sc.downloadChecksum(key, algorithm, checksum); algorithm != "" {
+			fd, err := os.Open(partFile)
+			if err != nil {
+				return err
+			}
+			err = checkChecksum(algorithm, checksum, fd)
+			fd.Close()
+			if err != nil {
+				// the partial file is corrupted, start over next time
+				os.Remove(partFile)
+				os.Remove(stateFile)
+				return fmt.Errorf("download %s failed: %w", key, err)
+			}
+		}
+	}
+	if err := renameFile(partFile, filename, opt.overwrite); err != nil {
+		return err
+	}
+	if err := os.Remove(stateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if sc.verboseOutput() {
+		fmt.Printf("download %s to %s, %d bytes(resumed at %d)\n", key, filename, remote.Size, offset)
+	} else if sc.lineOutput() {
+		fmt.Println(time.Now().Format(time.RFC3339), "download", filename)
+	}
+	return nil
+}
+
+// mpuJournal is the local state journal of a resumable Multi-Part-Upload of a file
+type mpuJournal struct {
+	Bucket   string           `json:"bucket"`
+	Key      string           `json:"key"`
+	File     string           `json:"file"`
+	Size     int64            `json:"size"`
+	ModTime  time.Time        `json:"modTime"`
+	UploadID string           `json:"uploadId"`
+	PartSize int64            `json:"partSize"`
+	Parts    map[int64]string `json:"parts"` // ETag of completed parts by part number
+	// checksum algorithm of the upload and checksums of completed parts by part number
+	ChecksumAlgorithm string           `json:"checksumAlgorithm,omitempty"`
+	Checksums         map[int64]string `json:"checksums,omitempty"`
+}
+
+// partCount returns the number of parts of the file
+func (j *mpuJournal) partCount() int64 {
+	if j.Size == 0 {
+		return 1
+	}
+	return (j.Size + j.PartSize - 1) / j.PartSize
+}
+
+// partRange returns the offset and size of part num(start from 1)
+func (j *mpuJournal) partRange(num int64) (int64, int64) {
+	offset := (num - 1) * j.PartSize
+	return offset, min(j.PartSize, j.Size-offset)
+}
+
+// mpuResume uploads file to bucket/key with a Multi-Part-Upload recorded in journalFile.
+// If the journal is left by an interrupted upload of the same file, the journal is reconciled
+// with the uploaded parts(ListParts) and only the missing parts are uploaded, otherwise the upload
+// of the stale journal is aborted.
+// The journal is removed after the upload is completed.
+func (sc *S3Cli) mpuResume(ctx context.Context, bucket, key, contentType, filename, journalFile string, partSize int64, concurrency int, metadata map[string]*string) error {
+	if sc.presign {
+		return errors.New("resumable mpu can not be presigned")
+	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	info, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+
+	j := &mpuJournal{}
+	jerr := readJSONFile(journalFile, j)
+	if jerr == nil && j.Bucket == bucket && j.Key == key &&
+		j.Size == info.Size() && j.ModTime.Equal(info.ModTime()) && j.UploadID != "" && j.PartSize > 0 &&
+		j.ChecksumAlgorithm == sc.checksum {
+		parts, err := sc.listAllParts(ctx, bucket, key, j.UploadID)
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchUpload {
+			j.UploadID = "" // aborted or completed, start over
+		} else if err != nil {
+			return fmt.Errorf("list parts of %s failed: %w", j.UploadID, err)
+		}
+		done := map[int64]string{}
+		checksums := map[int64]string{}
+		for _, p := range parts {
+			num := aws.Int64Value(p.PartNumber)
+			if num < 1 || num > j.partCount() {
+				continue
+			}
+			if _, size := j.partRange(num); size != aws.Int64Value(p.Size) {
+				continue
+			}
+			if etag, ok := j.Parts[num]; ok && etag != aws.StringValue(p.ETag) {
+				continue
+			}
+			done[num] = aws.StringValue(p.ETag)
+			if _, value := checksumOf(p); value != "" {
+				checksums[num] = value
+			}
+		}
+		j.Parts = done
+		j.Checksums = checksums
+	} else {
+		if jerr == nil && j.UploadID != "" {
+			// the journal is stale(e.g. the file changed), abort its upload so the parts are not left behind
+			req, _ := sc.Client.AbortMultipartUploadRequest(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(j.Bucket),
+				Key:      aws.String(j.Key),
+				UploadId: aws.String(j.UploadID),
+			})
+			req.SetContext(ctx)
+			sc.addCustomHeader(req.HTTPRequest)
+			req.Send() // the upload is left for mpu-clean if abort failed
+		}
+		j = &mpuJournal{Bucket: bucket, Key: key, File: filename, Size: info.Size(), ModTime: info.ModTime(), PartSize: partSize,
+			ChecksumAlgorithm: sc.checksum}
+	}
+	if j.partCount() > s3manager.MaxUploadParts {
+		return fmt.Errorf("%s needs %d parts of %d bytes, more than %d", filename, j.partCount(), j.PartSize, s3manager.MaxUploadParts)
+	}
+
+	if j.UploadID == "" {
+		input := &s3.CreateMultipartUploadInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			Metadata: metadata,
+		}
+		if contentType != "" {
+			input.ContentType = aws.String(contentType)
+		}
+		if sc.tagging != "" {
+			input.Tagging = aws.String(sc.tagging)
+		}
+		if sc.checksum != "" {
+			input.ChecksumAlgorithm = aws.String(sc.checksum)
+		}
+		sc.setObjectLock(input)
+		req, resp := sc.Client.CreateMultipartUploadRequest(input)
+		req.SetContext(ctx)
+		sc.addCustomHeader(req.HTTPRequest)
+		if err := req.Send(); err != nil {
+			return err
+		}
+		j.UploadID = aws.StringValue(resp.UploadId)
+		j.Parts = map[int64]string{}
+		j.Checksums = map[int64]string{}
+	}
+	if j.Checksums == nil {
+		j.Checksums = map[int64]string{}
+	}
+	if err := writeJSONFile(journalFile, j); err != nil {
+		return err
+	}
+
+	var missing []int64
+	for num := int64(1); num <= j.partCount(); num++ {
+		if _, ok := j.Parts[num]; !ok {
+			missing = append(missing, num)
+		}
+	}
+	if sc.verboseOutput() {
+		fmt.Printf("UploadId %s, %d of %d part(s) to upload\n", j.UploadID, len(missing), j.partCount())
+	}
+	ctx, p, finish := sc.startProgress(ctx, key, j.Size)
+	defer finish()
+	for num := range j.Parts {
+		_, size := j.partRange(num)
+		p.add(size)
+	}
+	mu := sync.Mutex{}
+	errs := make([]error, len(missing))
+	runWorkers(len(missing), concurrency, func(i int) {
+		num := missing[i]
+		offset, size := j.partRange(num)
+		input := &s3.UploadPartInput{
+			Body:       io.NewSectionReader(fd, offset, size),
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			PartNumber: aws.Int64(num),
+			UploadId:   aws.String(j.UploadID),
+		}
+		var checksum string
+		if sc.checksum != "" {
+			if checksum, errs[i] = computeChecksum(sc.checksum, input.Body); errs[i] != nil {
+				return
+			}
+			input.ChecksumAlgorithm = aws.String(sc.checksum)
+			setChecksum(input, sc.checksum, checksum)
+		}
+		req, resp := sc.Client.UploadPartRequest(input)
+		req.SetContext(ctx)
+		sc.addCustomHeader(req.HTTPRequest)
+		if errs[i] = req.Send(); errs[i] != nil {
+			fmt.Printf("%2d   error %s\n", num, errs[i])
+			return
+		}
+		if sc.verboseOutput() {
+			fmt.Printf("%2d success %s\n", num, aws.StringValue(resp.ETag))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		j.Parts[num] = aws.StringValue(resp.ETag)
+		if checksum != "" {
+			j.Checksums[num] = checksum
+		}
+		errs[i] = writeJSONFile(journalFile, j)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("mpu %s(run again with the journal %s to resume): %w", filename, journalFile, err)
+		}
+	}
+
+	parts := make([]*s3.CompletedPart, j.partCount())
+	for num, etag := range j.Parts {
+		parts[num-1] = &s3.CompletedPart{
+			PartNumber: aws.Int64(num),
+			ETag:       aws.String(etag),
+		}
+		if j.ChecksumAlgorithm != "" {
+			setChecksum(parts[num-1], j.ChecksumAlgorithm, j.Checksums[num])
+		}
+	}
+	if err := sc.mpuCompleteParts(ctx, bucket, key, j.UploadID, parts); err != nil {
+		return err
+	}
+	return os.Remove(journalFile)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+func Test_getObjectResume(t *testing.T) {
+	key := "getObjectResume"
+	_, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil)
+	if err != nil {
+		t.Fatalf("getObjectResume backend PutObject failed: %s", err)
+	}
+	state, err := s3cliTest.headDownloadState(context.Background(), testBucketName, key, "")
+	if err != nil {
+		t.Fatalf("headDownloadState failed: %s", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), key)
+	opt := downloadOptions{resume: true, overwrite: true}
+	cases := []struct {
+		name  string
+		state downloadState
+		part  []byte
+		want  []byte
+	}{
+		{"new", downloadState{}, nil, testObjectContent},
+		// the partial content is kept, only the tail is fetched
+		{"resume", state, []byte("XXXX"), append([]byte("XXXX"), testObjectContent[4:]...)},
+		{"complete", state, testObjectContent, testObjectContent},
+		{"changed", downloadState{ETag: "changed"}, []byte("XXXX"), testObjectContent},
+	}
+	for _, c := range cases {
+		if c.part != nil {
+			if err := os.WriteFile(filename+".part", c.part, 0644); err != nil {
+				t.Fatalf("failed to create partial file: %v", err)
+			}
+			if err := writeJSONFile(filename+".part.json", c.state); err != nil {
+				t.Fatalf("failed to create state file: %v", err)
+			}
+		}
+		if err := s3cliTest.getObject(context.Background(), testBucketName, key, "", "", filename, opt); err != nil {
+			t.Errorf("getObjectResume %s failed: %s", c.name, err)
+			continue
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil || !bytes.Equal(data, c.want) {
+			t.Errorf("getObjectResume %s expect content %s, got: %s, %v", c.name, c.want, data, err)
+		}
+		for _, f := range []string{filename + ".part", filename + ".part.json"} {
+			if _, err := os.Stat(f); err == nil {
+				t.Errorf("getObjectResume %s left %s", c.name, f)
+			}
+		}
+	}
+}
+
+func Test_mpuResume(t *testing.T) {
+	ctx := context.Background()
+	key := "mpuResume"
+	content := bytes.Repeat(testObjectContent, 2) // 36 bytes, 3 parts of 16 bytes at most
+	filename := filepath.Join(t.TempDir(), key)
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("failed to stat test file: %v", err)
+	}
+
+	// an interrupted upload: part 1 uploaded, part 2 in the journal but not on the server
+	cmu, err := s3cliTest.Client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(testBucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload failed: %s", err)
+	}
+	part, err := s3cliTest.Client.UploadPart(&s3.UploadPartInput{
+		Body:       bytes.NewReader(content[:16]),
+		Bucket:     aws.String(testBucketName),
+		Key:        aws.String(key),
+		PartNumber: aws.Int64(1),
+		UploadId:   cmu.UploadId,
+	})
+	if err != nil {
+		t.Fatalf("UploadPart failed: %s", err)
+	}
+	journal := filename + ".mpu.json"
+	err = writeJSONFile(journal, &mpuJournal{
+		Bucket:   testBucketName,
+		Key:      key,
+		File:     filename,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		UploadID: aws.StringValue(cmu.UploadId),
+		PartSize: 16,
+		Parts:    map[int64]string{1: aws.StringValue(part.ETag), 2: `"lost"`},
+	})
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+
+	if err := s3cliTest.mpuResume(ctx, testBucketName, key, "", filename, journal, 5<<20, 2, nil); err != nil {
+		t.Fatalf("mpuResume failed: %s", err)
+	}
+	obj, err := s3Backend.GetObject(testBucketName, key, nil)
+	if err != nil {
+		t.Fatalf("backend GetObject failed: %s", err)
+	}
+	defer obj.Contents.Close()
+	data, err := io.ReadAll(obj.Contents)
+	if err != nil || !bytes.Equal(data, content) {
+		t.Errorf("mpuResume expect content %s, got: %s, %v", content, data, err)
+	}
+	if _, err := os.Stat(journal); err == nil {
+		t.Errorf("mpuResume journal not removed")
+	}
+
+	// the file changed since the journal was written, the stale upload is aborted
+	stale, err := s3cliTest.Client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(testBucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload failed: %s", err)
+	}
+	err = writeJSONFile(journal, &mpuJournal{
+		Bucket:   testBucketName,
+		Key:      key,
+		File:     filename,
+		Size:     info.Size(),
+		ModTime:  info.ModTime().Add(-time.Hour),
+		UploadID: aws.StringValue(stale.UploadId),
+		PartSize: 16,
+	})
+	if err != nil {
+		t.Fatalf("failed to create journal: %v", err)
+	}
+	if err := s3cliTest.mpuResume(ctx, testBucketName, key, "", filename, journal, 5<<20, 2, nil); err != nil {
+		t.Fatalf("mpuResume of stale journal failed: %s", err)
+	}
+	if _, err := s3cliTest.listAllParts(ctx, testBucketName, key, aws.StringValue(stale.UploadId)); err == nil {
+		t.Errorf("mpuResume expect the stale upload %s aborted", aws.StringValue(stale.UploadId))
+	}
+}
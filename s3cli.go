@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha1"
@@ -9,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,6 +22,8 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
 
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
@@ -51,9 +55,48 @@ type S3Cli struct {
 	header     []string // custom header(s)
 	query      []string // custom query
 	debug      int
+	progress   bool   // show progress of transfers
+	checksum   string // checksum algorithm(CRC32, CRC32C, SHA1 or SHA256) of uploads
+	tagging    string // URL encoded tags(header x-amz-tagging) of uploads and copies
+	tagReplace bool   // replace the tags of copies with tagging(tagging directive REPLACE)
+	lockMode   string // Object Lock retention mode(GOVERNANCE or COMPLIANCE) of uploads
+	lockUntil  time.Time
+	legalHold  bool   // Object Lock legal hold of uploads
 	Client     *s3.S3 // manual init this field
 }
 
+// profileCli returns a S3Cli of profile in the shared config and credentials files(e.g. to copy between
+// two S3 services), its endpoint is endpoint_url of the profile or the endpoint of sc if not set.
+// It returns sc if profile is empty.
+func (sc *S3Cli) profileCli(profile string) (*S3Cli, error) {
+	if profile == "" {
+		return sc, nil
+	}
+	c := *sc
+	c.profile = profile
+	c.accessKey, c.secretKey, c.tokenKey = "", "", ""
+	if endpoint := sharedConfigEndpoint(profile); endpoint != "" {
+		c.endpoint = endpoint
+	}
+	client, err := newS3Client(&c)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", profile, err)
+	}
+	c.Client = client
+	return &c, nil
+}
+
+// splitProfile splits arg in format [profile:]bucket[/key] into profile and bucket[/key],
+// the part before ':' is a profile only if it is in the shared config or credentials file,
+// so a Key with ':'(e.g. key-12:00) is not taken as a profile.
+func (sc *S3Cli) splitProfile(arg string) (string, string) {
+	i := strings.Index(arg, ":")
+	if i < 0 || strings.Contains(arg[:i], "/") || !sharedConfigProfile(arg[:i]) {
+		return "", arg
+	}
+	return arg[:i], arg[i+1:]
+}
+
 // splitKeyValue splits a string into two parts using the given separator.
 // Returns the key and value, or the original string as key with empty value if separator not found.
 func (sc *S3Cli) splitKeyValue(data, sep string) (string, string) {
@@ -428,13 +471,26 @@ func (sc *S3Cli) bucketACLSet(ctx context.Context, bucket string, acl string) er
 	return err
 }
 
-// bucketPolicyGet get a Bucket's Policy
-func (sc *S3Cli) bucketPolicyGet(ctx context.Context, bucket string) error {
+// bucketPolicy returns a Bucket's Policy
+func (sc *S3Cli) bucketPolicy(ctx context.Context, bucket string) (string, error) {
 	req, resp := sc.Client.GetBucketPolicyRequest(&s3.GetBucketPolicyInput{
 		Bucket: aws.String(bucket),
 	})
 	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.Policy), nil
+}
+
+// bucketPolicyGet get a Bucket's Policy
+func (sc *S3Cli) bucketPolicyGet(ctx context.Context, bucket string) error {
 	if sc.presign {
+		req, _ := sc.Client.GetBucketPolicyRequest(&s3.GetBucketPolicyInput{
+			Bucket: aws.String(bucket),
+		})
+		req.SetContext(ctx)
 		s, err := req.Presign(sc.presignExp)
 		if err == nil {
 			fmt.Println(s)
@@ -442,20 +498,25 @@ func (sc *S3Cli) bucketPolicyGet(ctx context.Context, bucket string) error {
 		return err
 	}
 
-	sc.addCustomHeader(req.HTTPRequest)
-	err := req.Send()
+	policy, err := sc.bucketPolicy(ctx, bucket)
 	if err != nil {
 		return err
 	}
-	fmt.Println(aws.StringValue(resp.Policy))
+	fmt.Println(formatPolicy([]byte(policy), sc.lineOutput()))
 	return nil
 }
 
-// bucketPolicySet set a Bucket's Policy
-func (sc *S3Cli) bucketPolicySet(ctx context.Context, bucket, policy string) error {
+// bucketPolicySet set a Bucket's Policy, the policy is validated before the request if validate is true
+func (sc *S3Cli) bucketPolicySet(ctx context.Context, bucket, policy string, validate bool) error {
 	if policy == "" {
 		return errors.New("empty policy")
 	}
+	if validate {
+		var err error
+		if policy, err = checkPolicy(bucket, []byte(policy)); err != nil {
+			return err
+		}
+	}
 
 	req, resp := sc.Client.PutBucketPolicyRequest(&s3.PutBucketPolicyInput{
 		Bucket: aws.String(bucket),
@@ -471,8 +532,7 @@ func (sc *S3Cli) bucketPolicySet(ctx context.Context, bucket, policy string) err
 	}
 
 	sc.addCustomHeader(req.HTTPRequest)
-	err := req.Send()
-	if err != nil {
+	if err := req.Send(); err != nil {
 		return err
 	}
 	fmt.Println(*resp)
@@ -643,6 +703,99 @@ func (sc *S3Cli) putBucketCors(ctx context.Context, bucket, cfgFile string) erro
 // If stream is true, sets ContentLength to 0 for streaming uploads.
 // If presign is enabled, returns a presigned URL instead of uploading the object.
 func (sc *S3Cli) putObject(ctx context.Context, bucket, key, contentType string, metadata map[string]*string, stream bool, r io.ReadSeeker) error {
+	resp, err := sc.sendPutObject(ctx, bucket, key, contentType, metadata, stream, r)
+	if err != nil || resp == nil {
+		return err
+	}
+	sc.printUpload(key, aws.StringValue(resp.ETag), resp)
+	return nil
+}
+
+// printUpload prints the response resp of the upload of key
+func (sc *S3Cli) printUpload(key, etag string, resp interface{}) {
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	} else if sc.lineOutput() {
+		fmt.Println(
+			time.Now().Format(time.RFC3339),
+			"upload",
+			etag,
+			key,
+		)
+	}
+}
+
+// mpuOptions controls when and how a upload switches from a single PUT to mpu
+type mpuOptions struct {
+	threshold   int64 // upload content larger than threshold bytes with mpu
+	partSize    int64 // mpu part size in bytes
+	concurrency int   // mpu parts in flight
+}
+
+// putFile uploads the local file fd to bucket/key like putObject, or with mpu if it is larger than mo.threshold.
+func (sc *S3Cli) putFile(ctx context.Context, bucket, key, contentType string, metadata map[string]*string, stream bool, fd *os.File, mo mpuOptions) error {
+	etag, resp, err := sc.sendFile(ctx, bucket, key, contentType, metadata, stream, fd, mo)
+	if err != nil || resp == nil {
+		return err
+	}
+	sc.printUpload(key, etag, resp)
+	return nil
+}
+
+// sendFile uploads the local file fd like putFile and returns the ETag and response without printing it.
+// If presign is enabled, the presigned URL is printed and a nil response is returned.
+func (sc *S3Cli) sendFile(ctx context.Context, bucket, key, contentType string, metadata map[string]*string, stream bool, fd *os.File, mo mpuOptions) (string, interface{}, error) {
+	info, err := fd.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+	if !sc.presign {
+		var finish func()
+		ctx, _, finish = sc.startProgress(ctx, key, info.Size())
+		defer finish()
+	}
+	if info.Size() > mo.threshold && !sc.presign {
+		out, err := sc.sendMPU(ctx, bucket, key, contentType, mo.partSize, mo.concurrency, fd, metadata)
+		if err != nil {
+			return "", nil, err
+		}
+		return aws.StringValue(out.ETag), awsutil.Prettify(out), nil
+	}
+	resp, err := sc.sendPutObject(ctx, bucket, key, contentType, metadata, stream, fd)
+	if err != nil || resp == nil {
+		return "", nil, err
+	}
+	return aws.StringValue(resp.ETag), resp, nil
+}
+
+// putObjectStream uploads the content of r(e.g. stdin) of unknown length to bucket/key.
+// Up to mo.threshold bytes are buffered and uploaded with a single PUT, if r is larger,
+// the buffered and the rest content is uploaded with mpu.
+func (sc *S3Cli) putObjectStream(ctx context.Context, bucket, key, contentType string, metadata map[string]*string, mo mpuOptions, r io.Reader) error {
+	if sc.presign {
+		return sc.putObject(ctx, bucket, key, contentType, metadata, false, bytes.NewReader(nil))
+	}
+	ctx, p, finish := sc.startProgress(ctx, key, -1)
+	defer finish()
+	buf, err := io.ReadAll(io.LimitReader(r, mo.threshold+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(buf)) <= mo.threshold {
+		p.setTotal(int64(len(buf)))
+		return sc.putObject(ctx, bucket, key, contentType, metadata, false, bytes.NewReader(buf))
+	}
+	out, err := sc.sendMPU(ctx, bucket, key, contentType, mo.partSize, mo.concurrency, io.MultiReader(bytes.NewReader(buf), r), metadata)
+	if err != nil {
+		return err
+	}
+	sc.printUpload(key, aws.StringValue(out.ETag), awsutil.Prettify(out))
+	return nil
+}
+
+// sendPutObject sends the PutObject request of putObject and returns the response without printing it.
+// If presign is enabled, the presigned URL is printed and a nil response is returned.
+func (sc *S3Cli) sendPutObject(ctx context.Context, bucket, key, contentType string, metadata map[string]*string, stream bool, r io.ReadSeeker) (*s3.PutObjectOutput, error) {
 	var objContentType *string
 	if contentType != "" {
 		objContentType = aws.String(contentType)
@@ -658,8 +811,20 @@ func (sc *S3Cli) putObject(ctx context.Context, bucket, key, contentType string,
 	if stream {
 		putObjectInput.ContentLength = aws.Int64(0)
 	}
+	if sc.tagging != "" {
+		putObjectInput.Tagging = aws.String(sc.tagging)
+	}
+	sc.setObjectLock(putObjectInput)
 	if !reflect.ValueOf(r).IsNil() {
 		putObjectInput.Body = r
+		if sc.checksum != "" {
+			value, err := computeChecksum(sc.checksum, r)
+			if err != nil {
+				return nil, err
+			}
+			putObjectInput.ChecksumAlgorithm = aws.String(sc.checksum)
+			setChecksum(putObjectInput, sc.checksum, value)
+		}
 	}
 	req, resp := sc.Client.PutObjectRequest(putObjectInput)
 	req.SetContext(ctx)
@@ -669,35 +834,123 @@ func (sc *S3Cli) putObject(ctx context.Context, bucket, key, contentType string,
 		if err == nil {
 			fmt.Println(s)
 		}
-		return err
+		return nil, err
 	}
 
 	sc.addCustomHeader(req.HTTPRequest)
-	err := req.Send()
+	if err := req.Send(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// uploadResult is the result of uploading a local file
+type uploadResult struct {
+	File  string `json:"file"`
+	Key   string `json:"key"`
+	Size  int64  `json:"size"`
+	ETag  string `json:"etag,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// uploadDir uploads all files under local dir to bucket/prefix/<relative path> with at most
+// concurrency files in flight, and prints a per-file result summary.
+// If contentType is empty, it is detected from the extension of every file.
+func (sc *S3Cli) uploadDir(ctx context.Context, bucket, prefix, dir, contentType string, metadata map[string]*string, wo walkOptions, concurrency int, mo mpuOptions) error {
+	files, err := walkLocalFiles(dir, wo)
 	if err != nil {
 		return err
 	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if !sc.presign {
+		var total int64
+		for _, f := range files {
+			total += f.size
+		}
+		var finish func()
+		ctx, _, finish = sc.startProgress(ctx, dir, total)
+		defer finish()
+	}
 
-	if sc.verboseOutput() {
-		fmt.Println(resp.String())
-	} else if sc.lineOutput() {
-		fmt.Println(
-			time.Now().Format(time.RFC3339),
-			"upload",
-			aws.StringValue(resp.ETag),
-			key,
-		)
+	results := make([]uploadResult, len(files))
+	runWorkers(len(files), concurrency, func(i int) {
+		f := files[i]
+		results[i] = uploadResult{File: f.path, Key: prefix + f.rel, Size: f.size}
+		if err := ctx.Err(); err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		fd, err := os.Open(f.path)
+		if err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		defer fd.Close()
+		ct := contentType
+		if ct == "" {
+			ct = mime.TypeByExtension(filepath.Ext(f.path))
+		}
+		etag, _, err := sc.sendFile(ctx, bucket, results[i].Key, ct, metadata, false, fd, mo)
+		if err != nil {
+			results[i].Error = err.Error()
+			return
+		}
+		results[i].ETag = etag
+	})
+	if sc.presign {
+		return nil
+	}
+
+	var failed int
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Println(results)
+		} else {
+			fmt.Printf("%s", jo)
+		}
+	} else {
+		for _, r := range results {
+			status := "upload"
+			if r.Error != "" {
+				status = "failed"
+			}
+			if sc.lineOutput() {
+				fmt.Println(time.Now().Format(time.RFC3339), status, r.Size, r.File, r.Key, r.ETag+r.Error)
+			} else if r.Error != "" {
+				fmt.Printf("%s %s -> %s: %s\n", status, r.File, r.Key, r.Error)
+			} else {
+				fmt.Printf("%s %s -> %s\n", status, r.File, r.Key)
+			}
+		}
+		if sc.verboseOutput() {
+			fmt.Printf("%d file(s) uploaded, %d failed\n", len(results)-failed, failed)
+		}
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("upload %s: %d of %d file(s) failed", dir, failed, len(results))
+	}
 	return nil
 }
 
 // headObject head a Object
 func (sc *S3Cli) headObject(ctx context.Context, bucket, key string, mtime, mTimestamp bool) error {
-	req, resp := sc.Client.HeadObjectRequest(&s3.HeadObjectInput{
+	input := &s3.HeadObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-	})
+	}
+	if !sc.presign { // the stored checksum
+		input.ChecksumMode = aws.String(s3.ChecksumModeEnabled)
+	}
+	req, resp := sc.Client.HeadObjectRequest(input)
 	req.SetContext(ctx)
 
 	if sc.presign {
@@ -723,7 +976,11 @@ func (sc *S3Cli) headObject(ctx context.Context, bucket, key string, mtime, mTim
 	} else if mTimestamp {
 		fmt.Println(resp.LastModified.Unix())
 	} else if sc.lineOutput() {
-		fmt.Printf("%d\t%s\n", aws.Int64Value(resp.ContentLength), resp.LastModified)
+		if algorithm, checksum := checksumOf(resp); algorithm != "" {
+			fmt.Printf("%d\t%s\t%s:%s\n", aws.Int64Value(resp.ContentLength), resp.LastModified, algorithm, checksum)
+		} else {
+			fmt.Printf("%d\t%s\n", aws.Int64Value(resp.ContentLength), resp.LastModified)
+		}
 	} else if sc.jsonOutput() {
 		jo, err := json.MarshalIndent(resp, "", "  ")
 		if err != nil {
@@ -779,19 +1036,25 @@ func (sc *S3Cli) getObjectLockConfig(ctx context.Context, bucket string) error {
 	return nil
 }
 
-// getObjectLockConfig
-func (sc *S3Cli) putObjectLockConfig(ctx context.Context, bucket, enabled string) error {
+// putObjectLockConfig sets the Object Lock configuration of bucket, with a default retention
+// of mode(GOVERNANCE or COMPLIANCE) for days or years if mode is not empty.
+func (sc *S3Cli) putObjectLockConfig(ctx context.Context, bucket, enabled, mode string, days, years int64) error {
+	cfg := &s3.ObjectLockConfiguration{
+		ObjectLockEnabled: aws.String(enabled),
+	}
+	if mode != "" {
+		retention := &s3.DefaultRetention{Mode: aws.String(mode)}
+		if days > 0 {
+			retention.Days = aws.Int64(days)
+		}
+		if years > 0 {
+			retention.Years = aws.Int64(years)
+		}
+		cfg.Rule = &s3.ObjectLockRule{DefaultRetention: retention}
+	}
 	req, resp := sc.Client.PutObjectLockConfigurationRequest(&s3.PutObjectLockConfigurationInput{
-		Bucket: aws.String(bucket),
-		ObjectLockConfiguration: &s3.ObjectLockConfiguration{
-			ObjectLockEnabled: aws.String(enabled),
-			Rule: &s3.ObjectLockRule{
-				DefaultRetention: &s3.DefaultRetention{
-					Days: aws.Int64(2),
-					Mode: aws.String("COMPLIANCE"),
-				},
-			},
-		},
+		Bucket:                  aws.String(bucket),
+		ObjectLockConfiguration: cfg,
 	})
 	req.SetContext(ctx)
 
@@ -997,6 +1260,30 @@ func (sc *S3Cli) listAllObjectsV2(ctx context.Context, bucket, prefix, delimiter
 	return nil
 }
 
+// walkObjectsV2 calls fn for every Object start with prefix in bucket,
+// it stops and returns the first error returned by fn.
+func (sc *S3Cli) walkObjectsV2(ctx context.Context, bucket, prefix string, fn func(*s3.Object) error) error {
+	listInput := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+	}
+	if prefix != "" {
+		listInput.SetPrefix(prefix)
+	}
+	var fnErr error
+	err := sc.Client.ListObjectsV2PagesWithContext(ctx, listInput, func(p *s3.ListObjectsV2Output, _ bool) (shouldContinue bool) {
+		for _, obj := range p.Contents {
+			if fnErr = fn(obj); fnErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("list all objects failed: %w", err)
+	}
+	return fnErr
+}
+
 // listObjects (S3 listBucket)list Objects in specified bucket
 func (sc *S3Cli) listObjects(ctx context.Context, bucket, prefix, delimiter, marker string, maxKeys int64, index bool, startTime, endTime time.Time) error {
 	listInput := &s3.ListObjectsInput{
@@ -1184,8 +1471,19 @@ func (sc *S3Cli) listObjectVersions(ctx context.Context, bucket, prefix string)
 	return nil
 }
 
-// getObject download a Object from bucket
-func (sc *S3Cli) getObject(ctx context.Context, bucket, key, oRange, version string) error {
+// downloadOptions controls how getObject writes the local file
+type downloadOptions struct {
+	overwrite   bool  // overwrite the local file if it exists
+	partSize    int64 // download parts of partSize bytes in parallel, a single GET if 0
+	concurrency int   // parts in flight of parallel download
+	resume      bool  // keep the partial file of an interrupted download and resume it next time
+	checksum    bool  // validate the content with the checksum stored with the Object
+}
+
+// getObject download a Object from bucket to local file filename(default is the base name of key).
+// The Object is written to a temporary file in the same directory, which is renamed to filename
+// only after the whole body is written, so an interrupted download leaves no truncated file.
+func (sc *S3Cli) getObject(ctx context.Context, bucket, key, oRange, version, filename string, opt downloadOptions) error {
 	var objRange *string
 	if oRange != "" {
 		objRange = aws.String(fmt.Sprintf("bytes=%s", oRange))
@@ -1194,12 +1492,16 @@ func (sc *S3Cli) getObject(ctx context.Context, bucket, key, oRange, version str
 	if version != "" {
 		versionID = aws.String(version)
 	}
-	req, resp := sc.Client.GetObjectRequest(&s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket:    aws.String(bucket),
 		Key:       aws.String(key),
 		VersionId: versionID,
 		Range:     objRange,
-	})
+	}
+	if opt.checksum {
+		input.ChecksumMode = aws.String(s3.ChecksumModeEnabled)
+	}
+	req, resp := sc.Client.GetObjectRequest(input)
 	req.SetContext(ctx)
 
 	if sc.presign {
@@ -1210,27 +1512,192 @@ func (sc *S3Cli) getObject(ctx context.Context, bucket, key, oRange, version str
 		return err
 	}
 
+	if filename == "" {
+		filename = filepath.Base(key)
+	}
+	if !opt.overwrite {
+		if err := checkNotExist(filename); err != nil {
+			return err
+		}
+	}
+
+	ctx, _, finish := sc.startProgress(ctx, key, -1)
+	defer finish()
+	req.SetContext(ctx)
+	p := progressFrom(ctx)
+	if opt.resume && oRange == "" {
+		return sc.getObjectResume(ctx, bucket, key, version, filename, opt)
+	}
+	if opt.partSize > 0 && oRange == "" {
+		return sc.getObjectParallel(ctx, bucket, key, version, filename, opt)
+	}
+
 	sc.addCustomHeader(req.HTTPRequest)
 	err := req.Send()
 	if err != nil {
 		return fmt.Errorf("get object %s failed: %w", key, err)
 	}
 	defer resp.Body.Close()
+	p.setTotal(aws.Int64Value(resp.ContentLength))
 
-	// Create a file to write the S3 Object contents
-	filename := filepath.Base(key)
-	fd, err := os.Create(filename)
+	var algorithm, checksum string
+	if opt.checksum {
+		algorithm, checksum = checksumOf(resp)
+		algorithm, checksum = sc.downloadChecksum(key, algorithm, checksum)
+	}
+	err = writeFileAtomic(filename, opt.overwrite, func(fd *os.File) error {
+		if algorithm != "" {
+			return checkChecksum(algorithm, checksum, io.TeeReader(resp.Body, fd))
+		}
+		_, err := io.Copy(fd, resp.Body)
+		return err
+	})
 	if err != nil {
-		return sc.errorHandler(err)
+		return fmt.Errorf("download %s failed: %w", key, err)
 	}
-	defer fd.Close()
-	_, err = io.Copy(fd, resp.Body)
 	if sc.verboseOutput() {
 		fmt.Println(resp)
 	} else if sc.lineOutput() {
 		fmt.Println(time.Now().Format(time.RFC3339), "download", filename)
 	}
-	return err
+	return nil
+}
+
+// getObjectParallel download a Object to local file filename with ranged GETs of opt.partSize bytes,
+// at most opt.concurrency parts in flight, each part is written at its offset of the file.
+func (sc *S3Cli) getObjectParallel(ctx context.Context, bucket, key, version, filename string, opt downloadOptions) error {
+	downloader := s3manager.NewDownloaderWithClient(sc.Client, func(d *s3manager.Downloader) {
+		d.PartSize = opt.partSize
+		d.Concurrency = opt.concurrency
+		d.RequestOptions = append(d.RequestOptions, func(r *request.Request) {
+			r.Handlers.Build.PushBack(func(r *request.Request) {
+				sc.addCustomHeader(r.HTTPRequest)
+			})
+		})
+	})
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	if p := progressFrom(ctx); p != nil {
+		if state, err := sc.headDownloadState(ctx, bucket, key, version); err == nil {
+			p.setTotal(state.Size)
+		}
+	}
+	var algorithm, checksum string
+	if opt.checksum {
+		var err error
+		if algorithm, checksum, err = sc.headChecksum(ctx, bucket, key, version); err != nil {
+			return err
+		}
+		algorithm, checksum = sc.downloadChecksum(key, algorithm, checksum)
+	}
+
+	var n int64
+	err := writeFileAtomic(filename, opt.overwrite, func(fd *os.File) (err error) {
+		if n, err = downloader.DownloadWithContext(ctx, fd, input); err != nil || algorithm == "" {
+			return err
+		}
+		// the ranged GETs have no checksum, validate the whole file
+		return checkChecksum(algorithm, checksum, io.NewSectionReader(fd, 0, n))
+	})
+	if err != nil {
+		return fmt.Errorf("download %s failed: %w", key, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Printf("download %s to %s, %d bytes in part(s) of %d bytes\n", key, filename, n, opt.partSize)
+	} else if sc.lineOutput() {
+		fmt.Println(time.Now().Format(time.RFC3339), "download", filename)
+	}
+	return nil
+}
+
+// downloadPrefix download all Objects start with prefix in bucket to local dir with at most
+// concurrency Objects(or parts of a large Object if opt.partSize is set) in flight.
+// The Key hierarchy after the last '/' of prefix is recreated under dir, Objects that
+// would be written outside of dir are refused.
+func (sc *S3Cli) downloadPrefix(ctx context.Context, bucket, prefix, dir string, concurrency int, opt downloadOptions) error {
+	if sc.presign {
+		return errors.New("recursive download can not be presigned")
+	}
+	base := prefix[:strings.LastIndex(prefix, "/")+1]
+	var objects []*s3.Object
+	err := sc.walkObjectsV2(ctx, bucket, prefix, func(obj *s3.Object) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, obj := range objects {
+		total += aws.Int64Value(obj.Size)
+	}
+	ctx, _, finish := sc.startProgress(ctx, prefix, total)
+	defer finish()
+
+	errs := make([]error, len(objects))
+	download := func(i int, opt downloadOptions) {
+		key := aws.StringValue(objects[i].Key)
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			return
+		}
+		rel := strings.TrimPrefix(key, base)
+		if rel == "" { // placeholder Object of prefix itself
+			return
+		}
+		filename, err := localPath(dir, rel)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		if strings.HasSuffix(key, "/") { // directory placeholder Object
+			errs[i] = os.MkdirAll(filename, 0755)
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			errs[i] = err
+			return
+		}
+		errs[i] = sc.getObject(ctx, bucket, key, "", "", filename, opt)
+	}
+	// Objects larger than opt.partSize are downloaded one by one with opt.concurrency parts in flight
+	// after the others, so there are at most concurrency GETs in flight
+	var small, large []int
+	for i, obj := range objects {
+		if opt.partSize > 0 && aws.Int64Value(obj.Size) > opt.partSize {
+			large = append(large, i)
+		} else {
+			small = append(small, i)
+		}
+	}
+	singleOpt := opt
+	singleOpt.partSize = 0
+	runWorkers(len(small), concurrency, func(i int) {
+		download(small[i], singleOpt)
+	})
+	for _, i := range large {
+		download(i, opt)
+	}
+
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			fmt.Printf("download %s failed: %s\n", aws.StringValue(objects[i].Key), err)
+			failed = append(failed, err)
+		}
+	}
+	if sc.verboseOutput() {
+		fmt.Printf("%d Object(s) downloaded, %d failed\n", len(objects)-len(failed), len(failed))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("download %s/%s: %d of %d Object(s) failed, first error: %w", bucket, prefix, len(failed), len(objects), failed[0])
+	}
+	return nil
 }
 
 // catObject print Object contents
@@ -1268,10 +1735,61 @@ func (sc *S3Cli) catObject(ctx context.Context, bucket, key, oRange, version str
 	return err
 }
 
-// renameObject rename Object
+// renameObject rename Object source(bucket/key) to bucket/key.
+// The Object is copied(metadata and content-type preserved) first, and the source
+// is deleted only after the copy succeeded.
 func (sc *S3Cli) renameObject(ctx context.Context, source, bucket, key string) error {
-	// TODO: Copy and Delete Object
-	return fmt.Errorf("not impl")
+	if sc.presign {
+		return errors.New("rename can not be presigned")
+	}
+	srcBucket, srcKey := sc.splitKeyValue(source, "/")
+	if srcBucket == "" || srcKey == "" {
+		return fmt.Errorf("invalid source <bucket/key>: %s", source)
+	}
+	if srcBucket == bucket && srcKey == key {
+		return fmt.Errorf("source and destination are the same: %s", source)
+	}
+	mo := mpuOptions{threshold: defaultCopyMPUThreshold, partSize: defaultCopyPartSize, concurrency: s3manager.DefaultUploadConcurrency}
+	if err := sc.copyObjectAuto(ctx, source, bucket, key, "", nil, false, mo); err != nil {
+		return err
+	}
+	if err := sc.deleteObject(ctx, srcBucket, srcKey); err != nil {
+		return fmt.Errorf("delete source %s failed: %w", source, err)
+	}
+	if sc.lineOutput() {
+		fmt.Println(time.Now().Format(time.RFC3339), "rename", source, bucket+"/"+key)
+	}
+	return nil
+}
+
+// renamePrefix rename all Objects start with srcPrefix in srcBucket to dstBucket,
+// the srcPrefix of every Key is replaced with dstPrefix.
+func (sc *S3Cli) renamePrefix(ctx context.Context, srcBucket, srcPrefix, dstBucket, dstPrefix string) error {
+	if sc.presign {
+		return errors.New("rename can not be presigned")
+	}
+	if srcBucket == dstBucket && srcPrefix == dstPrefix {
+		return fmt.Errorf("source and destination are the same: %s/%s", srcBucket, srcPrefix)
+	}
+	// collect all Keys first, the destination may be listed again if it is under the source prefix
+	var keys []string
+	err := sc.walkObjectsV2(ctx, srcBucket, srcPrefix, func(obj *s3.Object) error {
+		keys = append(keys, aws.StringValue(obj.Key))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		newKey := dstPrefix + strings.TrimPrefix(k, srcPrefix)
+		if err := sc.renameObject(ctx, srcBucket+"/"+k, dstBucket, newKey); err != nil {
+			return err
+		}
+	}
+	if sc.verboseOutput() {
+		fmt.Printf("%d Objects renamed\n", len(keys))
+	}
+	return nil
 }
 
 // copyObjects copy Object to destBucket/key
@@ -1291,6 +1809,10 @@ func (sc *S3Cli) copyObject(ctx context.Context, source, dstBucket, dstKey, cont
 	} else {
 		ci.MetadataDirective = aws.String(s3.MetadataDirectiveCopy)
 	}
+	if sc.tagReplace {
+		ci.TaggingDirective = aws.String(s3.TaggingDirectiveReplace)
+		ci.Tagging = aws.String(sc.tagging)
+	}
 
 	req, resp := sc.Client.CopyObjectRequest(ci)
 	req.SetContext(ctx)
@@ -1315,6 +1837,176 @@ func (sc *S3Cli) copyObject(ctx context.Context, source, dstBucket, dstKey, cont
 	return nil
 }
 
+// copyObjectAuto copy Object like copyObject, or with copyObjectMPU if it is larger than mo.threshold.
+func (sc *S3Cli) copyObjectAuto(ctx context.Context, source, dstBucket, dstKey, contentType string, metadata map[string]*string, mdRp bool, mo mpuOptions) error {
+	if sc.presign {
+		return sc.copyObject(ctx, source, dstBucket, dstKey, contentType, metadata, mdRp)
+	}
+	srcBucket, srcKey := sc.splitKeyValue(source, "/")
+	req, head := sc.Client.HeadObjectRequest(&s3.HeadObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("head object %s failed: %w", source, err)
+	}
+	// an empty Object has no part to copy
+	if size := aws.Int64Value(head.ContentLength); size > 0 && size > mo.threshold {
+		return sc.copyObjectMPU(ctx, source, dstBucket, dstKey, contentType, metadata, mdRp, head, mo)
+	}
+	return sc.copyObject(ctx, source, dstBucket, dstKey, contentType, metadata, mdRp)
+}
+
+// copyObjectAcross copy Object srcBucket/srcKey of src(another S3 service) to dstBucket/dstKey.
+// The content is streamed from a GET of src into a PUT(or mpu if it is large) of sc, and like copyObject,
+// the metadata of source is kept unless mdRp is true.
+func (sc *S3Cli) copyObjectAcross(ctx context.Context, src *S3Cli, srcBucket, srcKey, dstBucket, dstKey, contentType string, metadata map[string]*string, mdRp bool, mo mpuOptions) error {
+	if sc.presign || src.presign {
+		return errors.New("copy between S3 services can not be presigned")
+	}
+	req, resp := src.Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	req.SetContext(ctx)
+	src.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("get object %s failed: %w", srcKey, err)
+	}
+	defer resp.Body.Close()
+	if !mdRp {
+		metadata = resp.Metadata
+		contentType = aws.StringValue(resp.ContentType)
+	}
+	tagging, err := sc.copyTagging(ctx, src, srcBucket, srcKey, aws.Int64Value(resp.TagCount))
+	if err != nil {
+		return err
+	}
+	dst := *sc
+	dst.tagging = tagging
+
+	// parts of mo.partSize are buffered in memory, it is increased if the Object does not fit in s3manager.MaxUploadParts
+	partSize := max(mo.partSize, s3manager.MinUploadPartSize, (aws.Int64Value(resp.ContentLength)+s3manager.MaxUploadParts-1)/s3manager.MaxUploadParts)
+	out, err := dst.sendMPU(ctx, dstBucket, dstKey, contentType, partSize, mo.concurrency, resp.Body, metadata)
+	if err != nil {
+		return fmt.Errorf("copy object failed: %w", err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(awsutil.Prettify(out))
+	}
+	return nil
+}
+
+// partRanges splits size bytes into ranges(first and last byte offset) of partSize bytes,
+// partSize is increased if it needs more than s3manager.MaxUploadParts parts.
+func partRanges(size, partSize int64) [][2]int64 {
+	if minPartSize := (size + s3manager.MaxUploadParts - 1) / s3manager.MaxUploadParts; partSize < minPartSize {
+		partSize = minPartSize
+	}
+	var ranges [][2]int64
+	for offset := int64(0); offset < size; offset += partSize {
+		ranges = append(ranges, [2]int64{offset, min(offset+partSize, size) - 1})
+	}
+	return ranges
+}
+
+// copyObjectMPU copy the Object source(with HeadObject output head) to dstBucket/dstKey with UploadPartCopy,
+// mo.concurrency parts of mo.partSize bytes in flight. Like copyObject, the metadata of source is kept
+// unless mdRp is true. The upload is aborted if any part failed.
+func (sc *S3Cli) copyObjectMPU(ctx context.Context, source, dstBucket, dstKey, contentType string, metadata map[string]*string, mdRp bool, head *s3.HeadObjectOutput, mo mpuOptions) error {
+	ci := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(dstBucket),
+		Key:    aws.String(dstKey),
+	}
+	if mdRp {
+		ci.Metadata = metadata
+		if contentType != "" {
+			ci.ContentType = aws.String(contentType)
+		}
+	} else {
+		ci.Metadata = head.Metadata
+		ci.ContentType = head.ContentType
+		ci.CacheControl = head.CacheControl
+		ci.ContentDisposition = head.ContentDisposition
+		ci.ContentEncoding = head.ContentEncoding
+		ci.ContentLanguage = head.ContentLanguage
+	}
+	srcBucket, srcKey := sc.splitKeyValue(source, "/")
+	tagging, err := sc.copyTagging(ctx, sc, srcBucket, srcKey, -1)
+	if err != nil {
+		return err
+	}
+	if tagging != "" {
+		ci.Tagging = aws.String(tagging)
+	}
+	req, cmu := sc.Client.CreateMultipartUploadRequest(ci)
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("copy object failed: %w", err)
+	}
+
+	ranges := partRanges(aws.Int64Value(head.ContentLength), mo.partSize)
+	parts := make([]*s3.CompletedPart, len(ranges))
+	errs := make([]error, len(ranges))
+	runWorkers(len(ranges), mo.concurrency, func(i int) {
+		if errs[i] = ctx.Err(); errs[i] != nil {
+			return
+		}
+		req, resp := sc.Client.UploadPartCopyRequest(&s3.UploadPartCopyInput{
+			Bucket:            aws.String(dstBucket),
+			Key:               aws.String(dstKey),
+			CopySource:        aws.String(source),
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", ranges[i][0], ranges[i][1])),
+			CopySourceIfMatch: head.ETag,
+			PartNumber:        aws.Int64(int64(i + 1)),
+			UploadId:          cmu.UploadId,
+		})
+		req.SetContext(ctx)
+		sc.addCustomHeader(req.HTTPRequest)
+		if errs[i] = req.Send(); errs[i] != nil {
+			return
+		}
+		parts[i] = &s3.CompletedPart{
+			ETag:       resp.CopyPartResult.ETag,
+			PartNumber: aws.Int64(int64(i + 1)),
+		}
+		if sc.verboseOutput() {
+			fmt.Printf("%2d success %s\n", i+1, aws.StringValue(resp.CopyPartResult.ETag))
+		}
+	})
+	for i, err := range errs {
+		if err != nil {
+			req, _ := sc.Client.AbortMultipartUploadRequest(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(dstBucket),
+				Key:      aws.String(dstKey),
+				UploadId: cmu.UploadId,
+			})
+			sc.addCustomHeader(req.HTTPRequest)
+			req.Send() // the upload is left for mpu-clean if abort failed
+			return fmt.Errorf("copy object part %d failed: %w", i+1, err)
+		}
+	}
+
+	req, resp := sc.Client.CompleteMultipartUploadRequest(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(dstBucket),
+		Key:             aws.String(dstKey),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		UploadId:        cmu.UploadId,
+	})
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("copy object failed: %w", err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
 // deletePrefix deletes all objects with the specified prefix in the given bucket.
 // It handles pagination automatically and returns an error if any delete operation fails.
 func (sc *S3Cli) deletePrefix(ctx context.Context, bucket, prefix string) error {
@@ -1555,10 +2247,14 @@ func (sc *S3Cli) restoreObject(ctx context.Context, bucket, key, version string)
 
 // mpuCreate create Multi-Part-Upload
 func (sc *S3Cli) mpuCreate(ctx context.Context, bucket, key string) error {
-	req, resp := sc.Client.CreateMultipartUploadRequest(&s3.CreateMultipartUploadInput{
+	input := &s3.CreateMultipartUploadInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-	})
+	}
+	if sc.checksum != "" {
+		input.ChecksumAlgorithm = aws.String(sc.checksum)
+	}
+	req, resp := sc.Client.CreateMultipartUploadRequest(input)
 	req.SetContext(ctx)
 
 	if sc.presign {
@@ -1582,6 +2278,14 @@ func (sc *S3Cli) mpuCreate(ctx context.Context, bucket, key string) error {
 // The file map contains part numbers as keys and local file paths as values.
 // Returns an error if any part fails to upload, with details about the number of failed parts.
 func (sc *S3Cli) mpuUpload(ctx context.Context, bucket, key, uid string, file map[int64]string) error {
+	var total int64
+	for _, filename := range file {
+		if info, err := os.Stat(filename); err == nil {
+			total += info.Size()
+		}
+	}
+	ctx, _, finish := sc.startProgress(ctx, key, total)
+	defer finish()
 	wg := sync.WaitGroup{}
 	errCh := make(chan error, len(file))
 	for i, localFile := range file {
@@ -1595,13 +2299,24 @@ func (sc *S3Cli) mpuUpload(ctx context.Context, bucket, key, uid string, file ma
 				return
 			}
 			defer fd.Close()
-			req, resp := sc.Client.UploadPartRequest(&s3.UploadPartInput{
+			input := &s3.UploadPartInput{
 				Body:       fd,
 				Bucket:     aws.String(bucket),
 				Key:        aws.String(key),
 				PartNumber: aws.Int64(num),
 				UploadId:   aws.String(uid),
-			})
+			}
+			if sc.checksum != "" {
+				value, err := computeChecksum(sc.checksum, fd)
+				if err != nil {
+					fmt.Printf("%2d   error %s\n", num, err)
+					errCh <- err
+					return
+				}
+				input.ChecksumAlgorithm = aws.String(sc.checksum)
+				setChecksum(input, sc.checksum, value)
+			}
+			req, resp := sc.Client.UploadPartRequest(input)
 			req.SetContext(ctx)
 
 			err = req.Send()
@@ -1666,19 +2381,45 @@ func (sc *S3Cli) mpuAbort(ctx context.Context, bucket, key, uid string) error {
 	return err
 }
 
-// mpuList list Multi-Part-Uploads
-func (sc *S3Cli) mpuList(ctx context.Context, bucket, prefix string) error {
-	var keyPrefix *string
+// listAllMultipartUploads returns all in-progress Multi-Part-Uploads(all pages merged into one output)
+func (sc *S3Cli) listAllMultipartUploads(ctx context.Context, bucket, prefix string) (*s3.ListMultipartUploadsOutput, error) {
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(bucket),
+	}
 	if prefix != "" {
-		keyPrefix = aws.String(prefix)
+		input.Prefix = aws.String(prefix)
 	}
-	req, resp := sc.Client.ListMultipartUploadsRequest(&s3.ListMultipartUploadsInput{
-		Bucket: aws.String(bucket),
-		Prefix: keyPrefix,
+	out := &s3.ListMultipartUploadsOutput{}
+	first := true
+	err := sc.Client.ListMultipartUploadsPagesWithContext(ctx, input, func(p *s3.ListMultipartUploadsOutput, _ bool) bool {
+		if first {
+			*out = *p
+			first = false
+		} else {
+			out.Uploads = append(out.Uploads, p.Uploads...)
+			out.CommonPrefixes = append(out.CommonPrefixes, p.CommonPrefixes...)
+		}
+		return true
+	}, func(r *request.Request) {
+		sc.addCustomHeader(r.HTTPRequest)
 	})
-	req.SetContext(ctx)
+	out.IsTruncated = aws.Bool(false)
+	out.NextKeyMarker = nil
+	out.NextUploadIdMarker = nil
+	return out, err
+}
 
+// mpuList list all Multi-Part-Uploads
+func (sc *S3Cli) mpuList(ctx context.Context, bucket, prefix string) error {
 	if sc.presign {
+		var keyPrefix *string
+		if prefix != "" {
+			keyPrefix = aws.String(prefix)
+		}
+		req, _ := sc.Client.ListMultipartUploadsRequest(&s3.ListMultipartUploadsInput{
+			Bucket: aws.String(bucket),
+			Prefix: keyPrefix,
+		})
 		s, err := req.Presign(sc.presignExp)
 		if err == nil {
 			fmt.Println(s)
@@ -1686,7 +2427,7 @@ func (sc *S3Cli) mpuList(ctx context.Context, bucket, prefix string) error {
 		return err
 	}
 
-	err := req.Send()
+	resp, err := sc.listAllMultipartUploads(ctx, bucket, prefix)
 	if err != nil {
 		return err
 	}
@@ -1705,7 +2446,161 @@ func (sc *S3Cli) mpuList(ctx context.Context, bucket, prefix string) error {
 	return err
 }
 
-// mpuComplete complete Multi-Part-Upload
+// mpuCleanResult is a stale Multi-Part-Upload found(and aborted) by mpuClean
+type mpuCleanResult struct {
+	Key       string    `json:"key"`
+	UploadID  string    `json:"uploadId"`
+	Initiated time.Time `json:"initiated"`
+	Size      int64     `json:"size"` // total size of uploaded parts
+	Error     string    `json:"error,omitempty"`
+}
+
+// mpuClean aborts all Multi-Part-Uploads(with prefix) initiated more than olderThan ago,
+// and prints the stale uploads with a summary. If dryRun is true, nothing is aborted.
+func (sc *S3Cli) mpuClean(ctx context.Context, bucket, prefix string, olderThan time.Duration, dryRun bool) error {
+	if sc.presign {
+		return errors.New("mpu-clean can not be presigned")
+	}
+	resp, err := sc.listAllMultipartUploads(ctx, bucket, prefix)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var results []mpuCleanResult
+	var failed int
+	var reclaimed int64
+	for _, u := range resp.Uploads {
+		initiated := aws.TimeValue(u.Initiated)
+		if initiated.After(cutoff) {
+			continue
+		}
+		r := mpuCleanResult{
+			Key:       aws.StringValue(u.Key),
+			UploadID:  aws.StringValue(u.UploadId),
+			Initiated: initiated,
+		}
+		if parts, err := sc.listAllParts(ctx, bucket, r.Key, r.UploadID); err == nil {
+			for _, p := range parts {
+				r.Size += aws.Int64Value(p.Size)
+			}
+		}
+		if !dryRun {
+			req, _ := sc.Client.AbortMultipartUploadRequest(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bucket),
+				Key:      aws.String(r.Key),
+				UploadId: aws.String(r.UploadID),
+			})
+			req.SetContext(ctx)
+			sc.addCustomHeader(req.HTTPRequest)
+			if err := req.Send(); err != nil {
+				r.Error = err.Error()
+				failed++
+			} else {
+				reclaimed += r.Size
+			}
+		}
+		results = append(results, r)
+	}
+
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Println(results)
+		} else {
+			fmt.Printf("%s", jo)
+		}
+	} else {
+		for _, r := range results {
+			var line string
+			if sc.lineOutput() {
+				line = fmt.Sprint(r.Initiated.Format(time.RFC3339), " ", r.Size, " ", r.Key, " ", r.UploadID)
+			} else {
+				line = fmt.Sprintf("abort %s %s(initiated %s, %d bytes)", r.Key, r.UploadID, r.Initiated.Format(time.RFC3339), r.Size)
+			}
+			if r.Error != "" {
+				line += " " + r.Error
+			}
+			fmt.Println(line)
+		}
+		if !sc.lineOutput() {
+			if dryRun {
+				fmt.Printf("%d of %d upload(s) initiated before %s would be aborted\n", len(results), len(resp.Uploads), cutoff.Format(time.RFC3339))
+			} else {
+				fmt.Printf("%d of %d upload(s) aborted, %d failed, %d bytes reclaimed\n", len(results)-failed, len(resp.Uploads), failed, reclaimed)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("mpu-clean: %d of %d upload(s) failed to abort", failed, len(results))
+	}
+	return nil
+}
+
+// listAllParts returns all uploaded parts of Multi-Part-Upload uid
+func (sc *S3Cli) listAllParts(ctx context.Context, bucket, key, uid string) ([]*s3.Part, error) {
+	var parts []*s3.Part
+	err := sc.Client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uid),
+	}, func(p *s3.ListPartsOutput, _ bool) bool {
+		parts = append(parts, p.Parts...)
+		return true
+	}, func(r *request.Request) {
+		sc.addCustomHeader(r.HTTPRequest)
+	})
+	return parts, err
+}
+
+// mpuParts list all uploaded parts of Multi-Part-Upload uid
+func (sc *S3Cli) mpuParts(ctx context.Context, bucket, key, uid string) error {
+	if sc.presign {
+		req, _ := sc.Client.ListPartsRequest(&s3.ListPartsInput{
+			Bucket:   aws.String(bucket),
+			Key:      aws.String(key),
+			UploadId: aws.String(uid),
+		})
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	parts, err := sc.listAllParts(ctx, bucket, key, uid)
+	if err != nil {
+		return fmt.Errorf("list parts of %s failed: %w", uid, err)
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(parts, "", "  ")
+		if err != nil {
+			fmt.Println(parts)
+			return nil
+		}
+		fmt.Printf("%s", jo)
+		return nil
+	}
+	var total int64
+	for _, p := range parts {
+		if sc.verboseOutput() {
+			fmt.Println(p)
+		} else if sc.lineOutput() {
+			fmt.Println(aws.Int64Value(p.PartNumber), aws.Int64Value(p.Size), aws.StringValue(p.ETag), aws.TimeValue(p.LastModified).Format(time.RFC3339))
+		} else {
+			fmt.Printf("%2d %s\n", aws.Int64Value(p.PartNumber), aws.StringValue(p.ETag))
+		}
+		total += aws.Int64Value(p.Size)
+	}
+	if sc.verboseOutput() {
+		fmt.Printf("%d part(s), %d bytes\n", len(parts), total)
+	}
+	return nil
+}
+
+// mpuComplete complete Multi-Part-Upload with eTags of part 1 to len(eTags),
+// all uploaded parts(ListParts, with their checksums) are completed if eTags is empty.
 func (sc *S3Cli) mpuComplete(ctx context.Context, bucket, key, uid string, eTags []string) error {
 	parts := make([]*s3.CompletedPart, len(eTags))
 	for i, v := range eTags {
@@ -1714,6 +2609,30 @@ func (sc *S3Cli) mpuComplete(ctx context.Context, bucket, key, uid string, eTags
 			ETag:       aws.String(v),
 		}
 	}
+	if len(eTags) == 0 && !sc.presign {
+		uploaded, err := sc.listAllParts(ctx, bucket, key, uid)
+		if err != nil {
+			return fmt.Errorf("list parts of %s failed: %w", uid, err)
+		}
+		if len(uploaded) == 0 {
+			return fmt.Errorf("no part uploaded to %s", uid)
+		}
+		for _, p := range uploaded {
+			part := &s3.CompletedPart{
+				PartNumber: p.PartNumber,
+				ETag:       p.ETag,
+			}
+			if algorithm, value := checksumOf(p); algorithm != "" {
+				setChecksum(part, algorithm, value)
+			}
+			parts = append(parts, part)
+		}
+	}
+	return sc.mpuCompleteParts(ctx, bucket, key, uid, parts)
+}
+
+// mpuCompleteParts complete Multi-Part-Upload with parts
+func (sc *S3Cli) mpuCompleteParts(ctx context.Context, bucket, key, uid string, parts []*s3.CompletedPart) error {
 	req, resp := sc.Client.CompleteMultipartUploadRequest(&s3.CompleteMultipartUploadInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
@@ -1741,21 +2660,15 @@ func (sc *S3Cli) mpuComplete(ctx context.Context, bucket, key, uid string, eTags
 }
 
 func (sc *S3Cli) mpu(ctx context.Context, bucket, key, contentType string, partSize int64, concurrency int, r io.Reader, metadata map[string]*string) error {
-	uploader := s3manager.NewUploaderWithClient(sc.Client, func(u *s3manager.Uploader) {
-		u.PartSize = partSize
-		u.Concurrency = concurrency
-	})
-
-	mi := &s3manager.UploadInput{
-		Bucket:   aws.String(bucket),
-		Key:      aws.String(key),
-		Metadata: metadata,
-		Body:     r,
-	}
-	if contentType != "" {
-		mi.ContentType = aws.String(contentType)
+	total := int64(-1)
+	if fd, ok := r.(*os.File); ok {
+		if info, err := fd.Stat(); err == nil && info.Mode().IsRegular() {
+			total = info.Size()
+		}
 	}
-	out, err := uploader.UploadWithContext(ctx, mi)
+	ctx, _, finish := sc.startProgress(ctx, key, total)
+	defer finish()
+	out, err := sc.sendMPU(ctx, bucket, key, contentType, partSize, concurrency, r, metadata)
 	if err != nil {
 		return err
 	}
@@ -1778,3 +2691,33 @@ func (sc *S3Cli) mpu(ctx context.Context, bucket, key, contentType string, partS
 	}
 	return nil
 }
+
+// sendMPU uploads r to bucket/key with s3manager.Uploader(mpu in parts of partSize bytes if r is larger)
+// and returns the output without printing it.
+func (sc *S3Cli) sendMPU(ctx context.Context, bucket, key, contentType string, partSize int64, concurrency int, r io.Reader, metadata map[string]*string) (*s3manager.UploadOutput, error) {
+	uploader := s3manager.NewUploaderWithClient(sc.Client, func(u *s3manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+		if sc.checksum != "" {
+			u.RequestOptions = append(u.RequestOptions, checksumRequestOption(sc.checksum))
+		}
+	})
+
+	mi := &s3manager.UploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		Metadata: metadata,
+		Body:     r,
+	}
+	if contentType != "" {
+		mi.ContentType = aws.String(contentType)
+	}
+	if sc.checksum != "" {
+		mi.ChecksumAlgorithm = aws.String(sc.checksum)
+	}
+	if sc.tagging != "" {
+		mi.Tagging = aws.String(sc.tagging)
+	}
+	sc.setObjectLock(mi)
+	return uploader.UploadWithContext(ctx, mi)
+}
@@ -6,14 +6,26 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"io"
 	mrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
 )
 
 var (
@@ -86,7 +98,7 @@ func Test_bucketPolicyGet(t *testing.T) {
 
 func Test_bucketPolicySet(t *testing.T) {
 	t.Skip("not ready to test - requires policy validation setup")
-	if err := s3cliTest.bucketPolicySet(context.Background(), testBucketName, "{}"); err != nil {
+	if err := s3cliTest.bucketPolicySet(context.Background(), testBucketName, "{}", true); err != nil {
 		t.Error("bucketPolicySet error: ", err)
 	}
 }
@@ -127,6 +139,89 @@ func Test_putObject(t *testing.T) {
 	}
 }
 
+func Test_putObjectStream(t *testing.T) {
+	cases := map[string][]byte{
+		"putObjectStream/small": testObjectContent,
+		"putObjectStream/large": bytes.Repeat(testObjectContent, (6<<20)/len(testObjectContent)), // 2 parts
+	}
+	for key, content := range cases {
+		mo := mpuOptions{threshold: 1 << 10, partSize: 5 << 20, concurrency: 2}
+		err := s3cliTest.putObjectStream(context.Background(), testBucketName, key, "", nil, mo, bytes.NewBuffer(content))
+		if err != nil {
+			t.Errorf("putObjectStream %s failed: %s", key, err)
+			continue
+		}
+		obj, err := s3Backend.HeadObject(testBucketName, key)
+		if err != nil {
+			t.Errorf("putObjectStream %s backend HeadObject failed: %s", key, err)
+			continue
+		}
+		if obj.Size != int64(len(content)) {
+			t.Errorf("putObjectStream %s expect size %d, got: %d", key, len(content), obj.Size)
+		}
+	}
+}
+
+func Test_putFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "putFile")
+	content := bytes.Repeat(testObjectContent, (6<<20)/len(testObjectContent))
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	defer fd.Close()
+
+	key := "putFile"
+	mo := mpuOptions{threshold: 1 << 10, partSize: 5 << 20, concurrency: 2}
+	md := map[string]*string{"k": aws.String("v")}
+	if err := s3cliTest.putFile(context.Background(), testBucketName, key, "text/plain", md, false, fd, mo); err != nil {
+		t.Errorf("putFile failed: %s", err)
+		return
+	}
+	obj, err := s3Backend.HeadObject(testBucketName, key)
+	if err != nil {
+		t.Errorf("putFile backend HeadObject failed: %s", err)
+		return
+	}
+	if obj.Size != int64(len(content)) {
+		t.Errorf("putFile expect size %d, got: %d", len(content), obj.Size)
+	}
+	if obj.Metadata["Content-Type"] != "text/plain" {
+		t.Errorf("putFile expect Content-Type text/plain, got: %v", obj.Metadata)
+	}
+}
+
+func Test_uploadDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.txt", "sub/b.txt", "sub/c.log"} {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed to create test dir: %v", err)
+		}
+		if err := os.WriteFile(p, testObjectContent, 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	wo := walkOptions{exclude: []string{"*.log"}}
+	mo := mpuOptions{threshold: defaultMPUThreshold, partSize: 5 << 20, concurrency: 2}
+	if err := s3cliTest.uploadDir(context.Background(), testBucketName, "uploadDir", dir, "", nil, wo, 2, mo); err != nil {
+		t.Errorf("uploadDir failed: %s", err)
+		return
+	}
+	for _, k := range []string{"uploadDir/a.txt", "uploadDir/sub/b.txt"} {
+		if _, err := s3Backend.HeadObject(testBucketName, k); err != nil {
+			t.Errorf("uploadDir backend HeadObject %s failed: %s", k, err)
+		}
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, "uploadDir/sub/c.log"); err == nil {
+		t.Errorf("uploadDir excluded file uploaded")
+	}
+}
+
 func Test_headObject(t *testing.T) {
 	if err := s3cliTest.headObject(context.Background(), testBucketName, testObjectKey, false, false); err != nil {
 		t.Errorf("headObject failed: %s", err)
@@ -164,12 +259,113 @@ func Test_listObjectVersions(t *testing.T) {
 }
 
 func Test_getObject(t *testing.T) {
-	err := s3cliTest.getObject(context.Background(), testBucketName, testObjectKey, "", "")
+	filename := filepath.Join(t.TempDir(), testObjectKey)
+	err := s3cliTest.getObject(context.Background(), testBucketName, testObjectKey, "", "", filename, downloadOptions{})
 	if err != nil {
 		t.Errorf("getObject failed: %s", err)
 		return
 	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("getObject local file: %s", err)
+	}
+	if err := s3cliTest.getObject(context.Background(), testBucketName, testObjectKey, "", "", filename, downloadOptions{}); err == nil {
+		t.Errorf("getObject expect error of existing local file")
+	}
+	if err := s3cliTest.getObject(context.Background(), testBucketName, testObjectKey, "", "", filename, downloadOptions{overwrite: true}); err != nil {
+		t.Errorf("getObject overwrite failed: %s", err)
+	}
+}
 
+func Test_getObjectParallel(t *testing.T) {
+	key := "getObjectParallel"
+	content := bytes.Repeat(testObjectContent, 10)
+	_, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(content), int64(len(content)), nil)
+	if err != nil {
+		t.Errorf("getObjectParallel backend PutObject failed: %s", err)
+		return
+	}
+
+	filename := filepath.Join(t.TempDir(), key)
+	opt := downloadOptions{partSize: 7, concurrency: 3}
+	if err := s3cliTest.getObject(context.Background(), testBucketName, key, "", "", filename, opt); err != nil {
+		t.Errorf("getObjectParallel failed: %s", err)
+		return
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Errorf("getObjectParallel read local file failed: %s", err)
+		return
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("getObjectParallel expect content %s, got: %s", content, data)
+	}
+}
+
+func Test_downloadPrefix(t *testing.T) {
+	keys := []string{"downloadPrefix/2024/k1", "downloadPrefix/2024/dir/k2", "downloadPrefix/2024/../../../k3"}
+	for _, k := range keys {
+		_, err := s3Backend.PutObject(testBucketName, k, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil)
+		if err != nil {
+			t.Errorf("downloadPrefix backend PutObject failed: %s", err)
+			return
+		}
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := s3cliTest.downloadPrefix(context.Background(), testBucketName, "downloadPrefix/2024", target, 2, downloadOptions{}); err == nil {
+		t.Errorf("downloadPrefix expect error of Key outside of target")
+	}
+	for _, f := range []string{"2024/k1", "2024/dir/k2"} {
+		if _, err := os.Stat(filepath.Join(target, filepath.FromSlash(f))); err != nil {
+			t.Errorf("downloadPrefix %s failed: %s", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "k3")); err == nil {
+		t.Errorf("downloadPrefix wrote outside of target")
+	}
+
+	// parts and Objects share the concurrency
+	for _, k := range []string{"downloadPrefix/parallel/k1", "downloadPrefix/parallel/k2", "downloadPrefix/parallel/small"} {
+		content := bytes.Repeat(testObjectContent, 10)
+		if strings.HasSuffix(k, "small") {
+			content = testObjectContent[:5]
+		}
+		if _, err := s3Backend.PutObject(testBucketName, k, nil, bytes.NewReader(content), int64(len(content)), nil); err != nil {
+			t.Fatalf("downloadPrefix backend PutObject failed: %s", err)
+		}
+	}
+	var running, maxRunning int64
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "" {
+			n := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			for {
+				m := atomic.LoadInt64(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+		faker.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+	opt := downloadOptions{partSize: 16, concurrency: 2}
+	if err := sc.downloadPrefix(context.Background(), testBucketName, "downloadPrefix/parallel/", t.TempDir(), 2, opt); err != nil {
+		t.Fatalf("downloadPrefix parallel failed: %s", err)
+	}
+	if maxRunning > 2 {
+		t.Errorf("downloadPrefix parallel expect at most 2 GETs in flight, got: %d", maxRunning)
+	}
 }
 
 func Test_catObject(t *testing.T) {
@@ -179,9 +375,54 @@ func Test_catObject(t *testing.T) {
 }
 
 func Test_renameObject(t *testing.T) {
-	t.Skip("not impl")
-	if err := s3cliTest.renameObject(context.Background(), "source", testBucketName, "key"); err != nil {
+	key := "keyToTestRenameObject"
+	_, err := s3Backend.PutObject(testBucketName, key, map[string]string{"Content-Type": "text/plain"}, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil)
+	if err != nil {
+		t.Errorf("renameObject backend PutObject failed: %s", err)
+		return
+	}
+
+	newKey := "keyToTestRenameObject.new"
+	if err := s3cliTest.renameObject(context.Background(), testBucketName+"/"+key, testBucketName, newKey); err != nil {
 		t.Errorf("renameObject failed: %s", err)
+		return
+	}
+	obj, err := s3Backend.HeadObject(testBucketName, newKey)
+	if err != nil {
+		t.Errorf("renameObject backend HeadObject failed: %s", err)
+		return
+	}
+	if obj.Metadata["Content-Type"] != "text/plain" {
+		t.Errorf("renameObject content-type expect: text/plain, got: %s", obj.Metadata["Content-Type"])
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, key); err == nil {
+		t.Errorf("renameObject source %s not deleted", key)
+	}
+}
+
+func Test_renamePrefix(t *testing.T) {
+	keys := []string{"renamePrefix/k1", "renamePrefix/dir/k2"}
+	for _, k := range keys {
+		_, err := s3Backend.PutObject(testBucketName, k, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil)
+		if err != nil {
+			t.Errorf("renamePrefix backend PutObject failed: %s", err)
+			return
+		}
+	}
+
+	if err := s3cliTest.renamePrefix(context.Background(), testBucketName, "renamePrefix/", testBucketName, "renamedPrefix/"); err != nil {
+		t.Errorf("renamePrefix failed: %s", err)
+		return
+	}
+	for _, k := range []string{"renamedPrefix/k1", "renamedPrefix/dir/k2"} {
+		if _, err := s3Backend.HeadObject(testBucketName, k); err != nil {
+			t.Errorf("renamePrefix backend HeadObject %s failed: %s", k, err)
+		}
+	}
+	for _, k := range keys {
+		if _, err := s3Backend.HeadObject(testBucketName, k); err == nil {
+			t.Errorf("renamePrefix source %s not deleted", k)
+		}
 	}
 }
 
@@ -197,6 +438,187 @@ func Test_copyObject(t *testing.T) {
 	}
 }
 
+func Test_copyObjectAuto(t *testing.T) {
+	source := fmt.Sprintf("%s/%s", testBucketName, testObjectKey)
+	newKey := "testCopyObjectAutoKey"
+	mo := mpuOptions{threshold: 5 << 30, partSize: 512 << 20, concurrency: 2}
+	if err := s3cliTest.copyObjectAuto(context.Background(), source, testBucketName, newKey, "", nil, false, mo); err != nil {
+		t.Errorf("copyObjectAuto failed: %s", err)
+		return
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, newKey); err != nil {
+		t.Errorf("copyObjectAuto backand HeadObject failed: %s", err)
+	}
+}
+
+func Test_copyObjectMPU(t *testing.T) {
+	// the fake S3 does not support UploadPartCopy, upload the source range as the part instead
+	faker := gofakes3.New(s3Backend).Server()
+	var copyRanges []string
+	mu := sync.Mutex{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		source := r.Header.Get("x-amz-copy-source")
+		if r.Method != http.MethodPut || source == "" || r.URL.Query().Get("uploadId") == "" {
+			faker.ServeHTTP(w, r)
+			return
+		}
+		var first, last int64
+		copyRange := r.Header.Get("x-amz-copy-source-range")
+		fmt.Sscanf(copyRange, "bytes=%d-%d", &first, &last)
+		source, _ = url.PathUnescape(source)
+		srcBucket, srcKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+		obj, err := s3Backend.GetObject(srcBucket, srcKey, &gofakes3.ObjectRangeRequest{Start: first, End: last})
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		defer obj.Contents.Close()
+		data, _ := io.ReadAll(obj.Contents)
+		mu.Lock()
+		copyRanges = append(copyRanges, copyRange)
+		mu.Unlock()
+
+		r.Header.Del("x-amz-copy-source")
+		r.Header.Del("x-amz-copy-source-range")
+		r.Header.Del("x-amz-copy-source-if-match")
+		r.Header.Set("Content-Length", strconv.Itoa(len(data)))
+		r.ContentLength = int64(len(data))
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		rec := httptest.NewRecorder()
+		faker.ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK {
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+		fmt.Fprintf(w, "<CopyPartResult><ETag>%s</ETag></CopyPartResult>", rec.Header().Get("ETag"))
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+
+	key := "copyObjectMPU"
+	content := bytes.Repeat(testObjectContent, 2) // 36 bytes, 3 parts of 16 bytes at most
+	if _, err := s3Backend.PutObject(testBucketName, key, map[string]string{"Content-Type": "text/plain"}, bytes.NewReader(content), int64(len(content)), nil); err != nil {
+		t.Fatalf("copyObjectMPU backend PutObject failed: %s", err)
+	}
+	mo := mpuOptions{threshold: 0, partSize: 16, concurrency: 2}
+	if err := sc.copyObjectAuto(context.Background(), testBucketName+"/"+key, testBucketName, key+".copy", "", nil, false, mo); err != nil {
+		t.Fatalf("copyObjectMPU failed: %s", err)
+	}
+	sort.Strings(copyRanges)
+	if want := []string{"bytes=0-15", "bytes=16-31", "bytes=32-35"}; !reflect.DeepEqual(copyRanges, want) {
+		t.Errorf("copyObjectMPU expect part ranges: %v, got: %v", want, copyRanges)
+	}
+	obj, err := s3Backend.GetObject(testBucketName, key+".copy", nil)
+	if err != nil {
+		t.Fatalf("copyObjectMPU backend GetObject failed: %s", err)
+	}
+	defer obj.Contents.Close()
+	if data, err := io.ReadAll(obj.Contents); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("copyObjectMPU expect content %s, got: %s, %v", content, data, err)
+	}
+	if obj.Metadata["Content-Type"] != "text/plain" {
+		t.Errorf("copyObjectMPU content-type expect: text/plain, got: %s", obj.Metadata["Content-Type"])
+	}
+
+	// an empty Object is copied with CopyObject
+	copyRanges = nil
+	if _, err := s3Backend.PutObject(testBucketName, key+".empty", nil, bytes.NewReader(nil), 0, nil); err != nil {
+		t.Fatalf("copyObjectMPU backend PutObject failed: %s", err)
+	}
+	if err := sc.copyObjectAuto(context.Background(), testBucketName+"/"+key+".empty", testBucketName, key+".empty.copy", "", nil, false, mo); err != nil {
+		t.Errorf("copyObjectAuto empty Object failed: %s", err)
+	}
+	if len(copyRanges) != 0 {
+		t.Errorf("copyObjectAuto empty Object expect no part, got: %v", copyRanges)
+	}
+}
+
+// setTestProfiles sets the shared config and credentials file of the test to files of content config and credentials
+func setTestProfiles(t *testing.T, config, credentials string) {
+	dir := t.TempDir()
+	for env, content := range map[string]string{"AWS_CONFIG_FILE": config, "AWS_SHARED_CREDENTIALS_FILE": credentials} {
+		name := filepath.Join(dir, env)
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to create %s: %v", env, err)
+		}
+		t.Setenv(env, name)
+	}
+}
+
+func Test_copyObjectAcross(t *testing.T) {
+	// another S3 service of profile other
+	backend := s3mem.New()
+	ts := httptest.NewServer(gofakes3.New(backend).Server())
+	defer ts.Close()
+	if err := backend.CreateBucket(testBucketName); err != nil {
+		t.Fatalf("backend CreateBucket failed: %s", err)
+	}
+	setTestProfiles(t, "[profile other]\nendpoint_url = "+ts.URL+"\n", "[other]\naws_access_key_id = ak\naws_secret_access_key = sk\n")
+
+	dst, err := s3cliTest.profileCli("other")
+	if err != nil {
+		t.Fatalf("profileCli failed: %s", err)
+	}
+	if dst.endpoint != ts.URL {
+		t.Errorf("profileCli expect endpoint %s, got: %s", ts.URL, dst.endpoint)
+	}
+	key := "testCopyObjectAcrossKey"
+	mo := mpuOptions{concurrency: 2}
+	if err := dst.copyObjectAcross(context.Background(), &s3cliTest, testBucketName, testObjectKey, testBucketName, key, "", nil, false, mo); err != nil {
+		t.Errorf("copyObjectAcross failed: %s", err)
+		return
+	}
+	if _, err := backend.HeadObject(testBucketName, key); err != nil {
+		t.Errorf("copyObjectAcross backend HeadObject failed: %s", err)
+	}
+}
+
+func Test_splitProfile(t *testing.T) {
+	setTestProfiles(t, "[profile p1]\nendpoint_url = http://127.0.0.1:9000\n", "[p2]\naws_access_key_id = ak\n")
+	cases := map[string][2]string{
+		"bucket/key":       {"", "bucket/key"},
+		"p1:bucket/key":    {"p1", "bucket/key"},
+		"p1:bucket":        {"p1", "bucket"},
+		"p2:bucket/key":    {"p2", "bucket/key"},
+		"bucket/key:colon": {"", "bucket/key:colon"},
+		"key-12:00":        {"", "key-12:00"},
+		"p3:bucket/key":    {"", "p3:bucket/key"},
+	}
+	for arg, want := range cases {
+		profile, rest := s3cliTest.splitProfile(arg)
+		if profile != want[0] || rest != want[1] {
+			t.Errorf("splitProfile(%s) expect: %v, got: %s, %s", arg, want, profile, rest)
+		}
+	}
+}
+
+func Test_partRanges(t *testing.T) {
+	cases := []struct {
+		size, partSize int64
+		want           [][2]int64
+	}{
+		{0, 10, nil},
+		{10, 10, [][2]int64{{0, 9}}},
+		{25, 10, [][2]int64{{0, 9}, {10, 19}, {20, 24}}},
+	}
+	for _, c := range cases {
+		if got := partRanges(c.size, c.partSize); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("partRanges(%d, %d) expect: %v, got: %v", c.size, c.partSize, c.want, got)
+		}
+	}
+	// part size is increased to fit in MaxUploadParts
+	if got := partRanges(5<<40, 512<<20); len(got) > 10000 {
+		t.Errorf("partRanges expect at most 10000 parts, got: %d", len(got))
+	}
+}
+
 func Test_deleteObjects(t *testing.T) {
 	prefix := "testPrefix"
 	if err := s3cliTest.deletePrefix(context.Background(), testBucketName, prefix); err != nil {
@@ -308,10 +730,97 @@ func Test_mpuList(t *testing.T) {
 	}
 }
 
+func Test_mpuParts(t *testing.T) {
+	key := fmt.Sprintf("mpu-parts-test-%s", randomString())
+	uploadID, err := createMultipartUpload(context.Background(), testBucketName, key)
+	if err != nil {
+		t.Skipf("unable to create multipart upload for testing: %v", err)
+		return
+	}
+	filePath := filepath.Join(t.TempDir(), "part1.txt")
+	if err := os.WriteFile(filePath, testObjectContent, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := s3cliTest.mpuUpload(context.Background(), testBucketName, key, uploadID, map[int64]string{1: filePath}); err != nil {
+		t.Fatalf("mpuUpload failed: %s", err)
+	}
+
+	if err := s3cliTest.mpuParts(context.Background(), testBucketName, key, uploadID); err != nil {
+		t.Errorf("mpuParts failed: %s", err)
+	}
+	parts, err := s3cliTest.listAllParts(context.Background(), testBucketName, key, uploadID)
+	if err != nil || len(parts) != 1 || aws.Int64Value(parts[0].PartNumber) != 1 {
+		t.Errorf("listAllParts expect part 1, got: %v, %v", parts, err)
+	}
+}
+
+func Test_mpuClean(t *testing.T) {
+	prefix := fmt.Sprintf("mpu-clean-test-%s/", randomString())
+	for i := 0; i < 2; i++ {
+		if _, err := createMultipartUpload(context.Background(), testBucketName, fmt.Sprintf("%sk%d", prefix, i)); err != nil {
+			t.Skipf("unable to create multipart upload for testing: %v", err)
+			return
+		}
+	}
+	uploads := func() int {
+		resp, err := s3cliTest.listAllMultipartUploads(context.Background(), testBucketName, prefix)
+		if err != nil {
+			t.Fatalf("listAllMultipartUploads failed: %s", err)
+		}
+		return len(resp.Uploads)
+	}
+
+	if err := s3cliTest.mpuClean(context.Background(), testBucketName, prefix, time.Hour, false); err != nil {
+		t.Errorf("mpuClean failed: %s", err)
+	}
+	if n := uploads(); n != 2 {
+		t.Errorf("mpuClean expect 2 recent uploads kept, got: %d", n)
+	}
+	if err := s3cliTest.mpuClean(context.Background(), testBucketName, prefix, 0, true); err != nil {
+		t.Errorf("mpuClean dry-run failed: %s", err)
+	}
+	if n := uploads(); n != 2 {
+		t.Errorf("mpuClean dry-run expect 2 uploads kept, got: %d", n)
+	}
+	if err := s3cliTest.mpuClean(context.Background(), testBucketName, prefix, 0, false); err != nil {
+		t.Errorf("mpuClean failed: %s", err)
+	}
+	if n := uploads(); n != 0 {
+		t.Errorf("mpuClean expect all uploads aborted, got: %d", n)
+	}
+}
+
 func Test_mpuComplete(t *testing.T) {
-	t.Skip("not ready to test - requires completed upload parts with ETags")
-	if err := s3cliTest.mpuComplete(context.Background(), testBucketName, "key", "upload-id", []string{"tag1", "tag2"}); err != nil {
+	key := fmt.Sprintf("mpu-complete-test-%s", randomString())
+	uploadID, err := createMultipartUpload(context.Background(), testBucketName, key)
+	if err != nil {
+		t.Skipf("unable to create multipart upload for testing: %v", err)
+		return
+	}
+	files := make(map[int64]string)
+	for i := int64(1); i <= 2; i++ {
+		filePath := filepath.Join(t.TempDir(), fmt.Sprintf("part%d.txt", i))
+		if err := os.WriteFile(filePath, testObjectContent, 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		files[i] = filePath
+	}
+	if err := s3cliTest.mpuUpload(context.Background(), testBucketName, key, uploadID, files); err != nil {
+		t.Fatalf("mpuUpload failed: %s", err)
+	}
+
+	// no ETags, complete with the uploaded parts
+	if err := s3cliTest.mpuComplete(context.Background(), testBucketName, key, uploadID, nil); err != nil {
 		t.Errorf("mpuComplete failed: %s", err)
+		return
+	}
+	obj, err := s3Backend.HeadObject(testBucketName, key)
+	if err != nil {
+		t.Errorf("mpuComplete backend HeadObject failed: %s", err)
+		return
+	}
+	if obj.Size != int64(2*len(testObjectContent)) {
+		t.Errorf("mpuComplete expect size %d, got: %d", 2*len(testObjectContent), obj.Size)
 	}
 }
 
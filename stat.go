@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// statPartHeads is the number of concurrent part heads of the HeadObject fallback of stat
+const statPartHeads = 8
+
+// objectStat is the attributes of a Object from GetObjectAttributes, or HeadObject and part heads
+type objectStat struct {
+	Bucket            string       `json:"bucket"`
+	Key               string       `json:"key"`
+	VersionID         string       `json:"versionId,omitempty"`
+	Size              int64        `json:"size"`
+	ETag              string       `json:"etag,omitempty"`
+	LastModified      time.Time    `json:"lastModified"`
+	StorageClass      string       `json:"storageClass,omitempty"`
+	ChecksumAlgorithm string       `json:"checksumAlgorithm,omitempty"`
+	Checksum          string       `json:"checksum,omitempty"`
+	PartsCount        int64        `json:"partsCount,omitempty"`
+	Parts             []objectPart `json:"parts,omitempty"`
+	Source            string       `json:"source"` // GetObjectAttributes or HeadObject
+}
+
+// objectPart is a part of multipart Object
+type objectPart struct {
+	PartNumber int64  `json:"partNumber"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"checksum,omitempty"`
+}
+
+// notImplemented reports whether err is the error of a API the endpoint does not implement,
+// some S3 compatible services(e.g. MinIO, Ceph) answer it with 400 InvalidRequest or InvalidArgument.
+func notImplemented(err error) bool {
+	var rerr awserr.RequestFailure
+	if errors.As(err, &rerr) {
+		switch rerr.StatusCode() {
+		case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+			return true
+		}
+		switch rerr.Code() {
+		case "NotImplemented", "MethodNotAllowed":
+			return true
+		case "InvalidRequest", "InvalidArgument":
+			return rerr.StatusCode() == http.StatusBadRequest
+		}
+	}
+	return false
+}
+
+// statObject returns the objectStat of bucket/key with GetObjectAttributes, or with HeadObject and
+// part heads(HeadObject with PartNumber) if the endpoint does not implement GetObjectAttributes.
+func (sc *S3Cli) statObject(ctx context.Context, bucket, key, version string) (*objectStat, error) {
+	st, err := sc.objectAttributes(ctx, bucket, key, version)
+	if err == nil && st != nil {
+		return st, nil
+	}
+	if err != nil && !notImplemented(err) {
+		return nil, fmt.Errorf("get object attributes %s failed: %w", key, err)
+	}
+	return sc.headObjectStat(ctx, bucket, key, version)
+}
+
+// objectAttributes returns the objectStat from GetObjectAttributes with all parts,
+// nil if the response has no attributes(e.g. the endpoint treats it as GetObject).
+func (sc *S3Cli) objectAttributes(ctx context.Context, bucket, key, version string) (*objectStat, error) {
+	st := &objectStat{Bucket: bucket, Key: key, Source: "GetObjectAttributes"}
+	var marker *int64
+	for {
+		input := &s3.GetObjectAttributesInput{
+			Bucket:           aws.String(bucket),
+			Key:              aws.String(key),
+			PartNumberMarker: marker,
+			ObjectAttributes: aws.StringSlice(s3.ObjectAttributes_Values()),
+		}
+		if version != "" {
+			input.VersionId = aws.String(version)
+		}
+		req, resp := sc.Client.GetObjectAttributesRequest(input)
+		req.SetContext(ctx)
+		sc.addCustomHeader(req.HTTPRequest)
+		if err := req.Send(); err != nil {
+			return nil, err
+		}
+		if marker == nil {
+			if resp.ETag == nil && resp.ObjectSize == nil {
+				return nil, nil
+			}
+			st.VersionID = aws.StringValue(resp.VersionId)
+			st.Size = aws.Int64Value(resp.ObjectSize)
+			st.ETag = aws.StringValue(resp.ETag)
+			st.LastModified = aws.TimeValue(resp.LastModified)
+			st.StorageClass = aws.StringValue(resp.StorageClass)
+			if resp.Checksum != nil {
+				st.ChecksumAlgorithm, st.Checksum = checksumOf(resp.Checksum)
+			}
+		}
+		op := resp.ObjectParts
+		if op == nil {
+			return st, nil
+		}
+		st.PartsCount = aws.Int64Value(op.TotalPartsCount)
+		for _, p := range op.Parts {
+			_, checksum := checksumOf(p)
+			st.Parts = append(st.Parts, objectPart{
+				PartNumber: aws.Int64Value(p.PartNumber),
+				Size:       aws.Int64Value(p.Size),
+				Checksum:   checksum,
+			})
+		}
+		if !aws.BoolValue(op.IsTruncated) || aws.Int64Value(op.NextPartNumberMarker) <= aws.Int64Value(marker) {
+			return st, nil
+		}
+		marker = op.NextPartNumberMarker
+	}
+}
+
+// headObjectStat returns the objectStat from HeadObject, the part sizes of multipart Object are
+// from HeadObject with PartNumber if the endpoint supports it.
+func (sc *S3Cli) headObjectStat(ctx context.Context, bucket, key, version string) (*objectStat, error) {
+	head := func(partNumber int64) (*s3.HeadObjectOutput, error) {
+		input := &s3.HeadObjectInput{
+			Bucket:       aws.String(bucket),
+			Key:          aws.String(key),
+			ChecksumMode: aws.String(s3.ChecksumModeEnabled),
+		}
+		if version != "" {
+			input.VersionId = aws.String(version)
+		}
+		if partNumber > 0 {
+			input.PartNumber = aws.Int64(partNumber)
+		}
+		req, resp := sc.Client.HeadObjectRequest(input)
+		req.SetContext(ctx)
+		sc.addCustomHeader(req.HTTPRequest)
+		if err := req.Send(); err != nil {
+			return nil, fmt.Errorf("head object %s failed: %w", key, err)
+		}
+		return resp, nil
+	}
+
+	resp, err := head(0)
+	if err != nil {
+		return nil, err
+	}
+	st := &objectStat{
+		Bucket:       bucket,
+		Key:          key,
+		VersionID:    aws.StringValue(resp.VersionId),
+		Size:         aws.Int64Value(resp.ContentLength),
+		ETag:         aws.StringValue(resp.ETag),
+		LastModified: aws.TimeValue(resp.LastModified),
+		StorageClass: aws.StringValue(resp.StorageClass),
+		Source:       "HeadObject",
+	}
+	st.ChecksumAlgorithm, st.Checksum = checksumOf(resp)
+	if _, parts, ok := parseETag(st.ETag); ok && parts > 0 {
+		st.PartsCount = int64(parts)
+	}
+	if st.PartsCount == 0 {
+		return st, nil
+	}
+
+	// the part heads are ignored if the endpoint does not support PartNumber
+	first, err := head(1)
+	if err != nil || aws.Int64Value(first.PartsCount) != st.PartsCount {
+		return st, nil
+	}
+	parts := make([]objectPart, st.PartsCount)
+	errs := make([]error, st.PartsCount)
+	runWorkers(int(st.PartsCount), statPartHeads, func(i int) {
+		resp := first
+		if i > 0 {
+			if resp, errs[i] = head(int64(i + 1)); errs[i] != nil {
+				return
+			}
+		}
+		_, checksum := checksumOf(resp)
+		parts[i] = objectPart{PartNumber: int64(i + 1), Size: aws.Int64Value(resp.ContentLength), Checksum: checksum}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	st.Parts = parts
+	return st, nil
+}
+
+// stat prints the size, ETag, storage class, checksum and parts of a Object
+func (sc *S3Cli) stat(ctx context.Context, bucket, key, version string) error {
+	if sc.presign {
+		input := &s3.GetObjectAttributesInput{
+			Bucket:           aws.String(bucket),
+			Key:              aws.String(key),
+			ObjectAttributes: aws.StringSlice(s3.ObjectAttributes_Values()),
+		}
+		if version != "" {
+			input.VersionId = aws.String(version)
+		}
+		req, _ := sc.Client.GetObjectAttributesRequest(input)
+		req.SetContext(ctx)
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	st, err := sc.statObject(ctx, bucket, key, version)
+	if err != nil {
+		return err
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			fmt.Println(st)
+			return nil
+		}
+		fmt.Printf("%s", jo)
+		return nil
+	}
+	if sc.lineOutput() {
+		checksum := "-"
+		if st.Checksum != "" {
+			checksum = st.ChecksumAlgorithm + ":" + st.Checksum
+		}
+		fmt.Printf("%d\t%s\t%s\t%s\t%d\t%s\n", st.Size, st.LastModified.Format(time.RFC3339), st.ETag, st.StorageClass, st.PartsCount, checksum)
+		return nil
+	}
+
+	fmt.Printf("Bucket       : %s\n", st.Bucket)
+	fmt.Printf("Key          : %s\n", st.Key)
+	if st.VersionID != "" {
+		fmt.Printf("VersionId    : %s\n", st.VersionID)
+	}
+	fmt.Printf("Size         : %d(%s)\n", st.Size, formatBytes(st.Size))
+	fmt.Printf("ETag         : %s\n", st.ETag)
+	fmt.Printf("LastModified : %s\n", st.LastModified.Format(time.RFC3339))
+	if st.StorageClass != "" {
+		fmt.Printf("StorageClass : %s\n", st.StorageClass)
+	}
+	if st.Checksum != "" {
+		fmt.Printf("Checksum     : %s %s\n", st.ChecksumAlgorithm, st.Checksum)
+	}
+	if st.PartsCount > 0 {
+		fmt.Printf("Parts        : %d\n", st.PartsCount)
+		for _, p := range st.Parts {
+			fmt.Printf("  %5d %12d(%s) %s\n", p.PartNumber, p.Size, formatBytes(p.Size), p.Checksum)
+		}
+	}
+	if sc.verboseOutput() {
+		fmt.Printf("Source       : %s\n", st.Source)
+	}
+	return nil
+}
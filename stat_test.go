@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+)
+
+func Test_statObject(t *testing.T) {
+	// GetObjectAttributes of a Object of 2 parts, 1 part per page, or not implemented
+	var implemented bool
+	errCode, errStatus := "NotImplemented", http.StatusNotImplemented
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["attributes"]; !ok {
+			faker.ServeHTTP(w, r)
+			return
+		}
+		if !implemented {
+			w.WriteHeader(errStatus)
+			fmt.Fprintf(w, `<Error><Code>%s</Code><Message>not implemented</Message></Error>`, errCode)
+			return
+		}
+		part := `<PartsCount>2</PartsCount><IsTruncated>true</IsTruncated><NextPartNumberMarker>1</NextPartNumberMarker>` +
+			`<Part><PartNumber>1</PartNumber><Size>10</Size><ChecksumCRC32>p1==</ChecksumCRC32></Part>`
+		if r.Header.Get("x-amz-part-number-marker") == "1" {
+			part = `<PartsCount>2</PartsCount><IsTruncated>false</IsTruncated>` +
+				`<Part><PartNumber>2</PartNumber><Size>8</Size><ChecksumCRC32>p2==</ChecksumCRC32></Part>`
+		}
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("x-amz-version-id", "v1")
+		fmt.Fprintf(w, `<GetObjectAttributesResponse><ETag>etag-2</ETag><Checksum><ChecksumCRC32>c==-2</ChecksumCRC32></Checksum>`+
+			`<ObjectParts>%s</ObjectParts><StorageClass>STANDARD</StorageClass><ObjectSize>18</ObjectSize></GetObjectAttributesResponse>`, part)
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+	key := "statObject"
+	if _, err := s3Backend.PutObject(testBucketName, key, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil); err != nil {
+		t.Fatalf("statObject backend PutObject failed: %s", err)
+	}
+
+	implemented = true
+	st, err := sc.statObject(context.Background(), testBucketName, key, "")
+	if err != nil {
+		t.Fatalf("statObject failed: %s", err)
+	}
+	want := []objectPart{{1, 10, "p1=="}, {2, 8, "p2=="}}
+	if st.Source != "GetObjectAttributes" || st.Size != 18 || st.VersionID != "v1" || st.StorageClass != "STANDARD" ||
+		st.ChecksumAlgorithm != "CRC32" || st.Checksum != "c==-2" || st.PartsCount != 2 || !reflect.DeepEqual(st.Parts, want) {
+		t.Errorf("statObject unexpected GetObjectAttributes stat: %+v", st)
+	}
+
+	implemented = false
+	st, err = sc.statObject(context.Background(), testBucketName, key, "")
+	if err != nil {
+		t.Fatalf("statObject HeadObject failed: %s", err)
+	}
+	if st.Source != "HeadObject" || st.Size != int64(len(testObjectContent)) || st.ETag == "" {
+		t.Errorf("statObject unexpected HeadObject stat: %+v", st)
+	}
+
+	// MinIO and Ceph answer GetObjectAttributes with 400
+	for _, code := range []string{"InvalidRequest", "InvalidArgument"} {
+		errCode, errStatus = code, http.StatusBadRequest
+		st, err = sc.statObject(context.Background(), testBucketName, key, "")
+		if err != nil || st.Source != "HeadObject" {
+			t.Errorf("statObject %s expect HeadObject stat, got: %+v, %v", code, st, err)
+		}
+	}
+	// other 400 errors are not a fallback
+	errCode, errStatus = "InvalidBucketName", http.StatusBadRequest
+	if _, err = sc.statObject(context.Background(), testBucketName, key, ""); err == nil {
+		t.Errorf("statObject %s expect error", errCode)
+	}
+
+	// the fake S3 serves GetObjectAttributes as GetObject
+	st, err = s3cliTest.statObject(context.Background(), testBucketName, key, "")
+	if err != nil || st.Source != "HeadObject" {
+		t.Errorf("statObject expect HeadObject stat, got: %+v, %v", st, err)
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	syncUpload   = "upload"
+	syncDownload = "download"
+	syncCopy     = "copy"
+	syncDelete   = "delete"
+)
+
+// syncLocation is the source or destination of sync, a local directory or [profile:]bucket/prefix
+type syncLocation struct {
+	dir     string // local directory, empty if it is a bucket
+	profile string // profile of another S3 service, see profileCli
+	bucket  string
+	prefix  string // always ends with '/' if not empty
+
+	cli *S3Cli // S3Cli of profile, set by syncDir
+}
+
+// parseSyncLocation parses arg as a local directory if it is an absolute path or starts with '.',
+// otherwise as [profile:]bucket[/prefix].
+func (sc *S3Cli) parseSyncLocation(arg string) syncLocation {
+	if filepath.IsAbs(arg) || strings.HasPrefix(arg, ".") {
+		return syncLocation{dir: arg}
+	}
+	profile, arg := sc.splitProfile(arg)
+	bucket, prefix := sc.splitKeyValue(arg, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return syncLocation{profile: profile, bucket: bucket, prefix: prefix}
+}
+
+// client returns the S3Cli of l, sc if l is not resolved by syncDir
+func (sc *S3Cli) client(l syncLocation) *S3Cli {
+	if l.cli != nil {
+		return l.cli
+	}
+	return sc
+}
+
+func (l syncLocation) local() bool {
+	return l.dir != ""
+}
+
+// overlaps reports whether the Objects of l and o(both buckets) overlap, e.g. bucket/ and bucket/backup/
+func (l syncLocation) overlaps(o syncLocation) bool {
+	if l.local() || o.local() || l.profile != o.profile || l.bucket != o.bucket {
+		return false
+	}
+	return strings.HasPrefix(l.prefix, o.prefix) || strings.HasPrefix(o.prefix, l.prefix)
+}
+
+// path returns the local path or [profile:]bucket/key of relative path rel
+func (l syncLocation) path(rel string) string {
+	if l.local() {
+		return filepath.Join(l.dir, filepath.FromSlash(rel))
+	}
+	if l.profile != "" {
+		return l.profile + ":" + l.bucket + "/" + l.prefix + rel
+	}
+	return l.bucket + "/" + l.prefix + rel
+}
+
+// syncEntry is a local file or Object to compare
+type syncEntry struct {
+	size    int64
+	modTime time.Time
+	etag    string // Objects only
+}
+
+// syncAction is a planned(or done) transfer or deletion of sync
+type syncAction struct {
+	Action string `json:"action"`
+	Reason string `json:"reason"` // new, size, mtime, etag or extraneous
+	Source string `json:"source,omitempty"`
+	Dest   string `json:"dest"`
+	Size   int64  `json:"size"`
+	Error  string `json:"error,omitempty"`
+
+	rel     string
+	modTime time.Time
+}
+
+// syncEntries returns all entries of l keyed by slash separated relative path.
+// A local directory that does not exist has no entries.
+func (sc *S3Cli) syncEntries(ctx context.Context, l syncLocation) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	if l.local() {
+		files, err := walkLocalFiles(l.dir, walkOptions{})
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			entries[f.rel] = syncEntry{size: f.size, modTime: f.modTime}
+		}
+		return entries, nil
+	}
+	err := sc.client(l).walkObjectsV2(ctx, l.bucket, l.prefix, func(obj *s3.Object) error {
+		key := aws.StringValue(obj.Key)
+		if strings.HasSuffix(key, "/") { // directory placeholder Object
+			return nil
+		}
+		entries[strings.TrimPrefix(key, l.prefix)] = syncEntry{
+			size:    aws.Int64Value(obj.Size),
+			modTime: aws.TimeValue(obj.LastModified),
+			etag:    strings.Trim(aws.StringValue(obj.ETag), `"`),
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// syncReason returns why the source entry s should be transferred to the destination entry d,
+// or empty string if they are the same.
+// Entries differ if their size or single part ETags differ, or the source is newer and the local MD5
+// does not match the single part ETag. A multipart ETag(e.g. of a copy across profiles) never equals
+// the single part ETag of the same content, so only the size and mtime are compared.
+func syncReason(s, d syncEntry, localFile string) (string, error) {
+	if s.size != d.size {
+		return "size", nil
+	}
+	multipart := multipartETag(s.etag) || multipartETag(d.etag)
+	if s.etag != "" && d.etag != "" && !multipart { // bucket to bucket
+		if s.etag != d.etag {
+			return "etag", nil
+		}
+		return "", nil
+	}
+	if !s.modTime.Truncate(time.Second).After(d.modTime.Truncate(time.Second)) {
+		return "", nil
+	}
+	etag := s.etag + d.etag
+	if etag == "" || multipart { // unknown or multipart ETag
+		return "mtime", nil
+	}
+	fd, err := os.Open(localFile)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return "", err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != etag {
+		return "etag", nil
+	}
+	return "", nil
+}
+
+// syncPlan compares src with dst and returns the actions(sorted by relative path) to make dst the same as src.
+// Extraneous dst entries are deleted only if del is true.
+func (sc *S3Cli) syncPlan(ctx context.Context, src, dst syncLocation, del bool) ([]syncAction, error) {
+	srcEntries, err := sc.syncEntries(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	dstEntries, err := sc.syncEntries(ctx, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	action := syncCopy
+	if src.local() {
+		action = syncUpload
+	} else if dst.local() {
+		action = syncDownload
+	}
+	var actions []syncAction
+	for rel, s := range srcEntries {
+		reason := "new"
+		if d, ok := dstEntries[rel]; ok {
+			localFile := ""
+			if src.local() {
+				localFile = src.path(rel)
+			} else if dst.local() {
+				localFile = dst.path(rel)
+			}
+			if reason, err = syncReason(s, d, localFile); err != nil {
+				return nil, err
+			}
+			if reason == "" {
+				continue
+			}
+		}
+		actions = append(actions, syncAction{
+			Action:  action,
+			Reason:  reason,
+			Source:  src.path(rel),
+			Dest:    dst.path(rel),
+			Size:    s.size,
+			rel:     rel,
+			modTime: s.modTime,
+		})
+	}
+	if del {
+		for rel, d := range dstEntries {
+			if _, ok := srcEntries[rel]; ok {
+				continue
+			}
+			actions = append(actions, syncAction{
+				Action: syncDelete,
+				Reason: "extraneous",
+				Dest:   dst.path(rel),
+				Size:   d.size,
+				rel:    rel,
+			})
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].rel < actions[j].rel
+	})
+	return actions, nil
+}
+
+// multipartETag reports whether etag is the ETag of a multipart Object(<md5>-<parts>), or an unknown
+// ETag with '-' that can not be a MD5
+func multipartETag(etag string) bool {
+	_, parts, ok := parseETag(etag)
+	return parts > 0 || !ok && strings.Contains(etag, "-")
+}
+
+// syncDo does action a of sync from src to dst
+func (sc *S3Cli) syncDo(ctx context.Context, src, dst syncLocation, a syncAction) error {
+	switch a.Action {
+	case syncUpload:
+		fd, err := os.Open(a.Source)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		mo := mpuOptions{threshold: defaultMPUThreshold, partSize: defaultPartSize, concurrency: 1}
+		_, _, err = sc.client(dst).sendFile(ctx, dst.bucket, dst.prefix+a.rel, mime.TypeByExtension(filepath.Ext(a.Source)), nil, false, fd, mo)
+		return err
+	case syncDownload:
+		filename, err := localPath(dst.dir, a.rel)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		if err := sc.client(src).getObject(ctx, src.bucket, src.prefix+a.rel, "", "", filename, downloadOptions{overwrite: true}); err != nil {
+			return err
+		}
+		// keep the mtime of Object, so it is the same next time
+		return os.Chtimes(filename, a.modTime, a.modTime)
+	case syncCopy:
+		mo := mpuOptions{threshold: defaultCopyMPUThreshold, partSize: defaultCopyPartSize, concurrency: 1}
+		if src.profile != dst.profile {
+			mo.partSize = defaultPartSize // parts are buffered in memory
+			return sc.client(dst).copyObjectAcross(ctx, sc.client(src), src.bucket, src.prefix+a.rel, dst.bucket, dst.prefix+a.rel, "", nil, false, mo)
+		}
+		return sc.client(dst).copyObjectAuto(ctx, src.bucket+"/"+src.prefix+a.rel, dst.bucket, dst.prefix+a.rel, "", nil, false, mo)
+	case syncDelete:
+		if dst.local() {
+			return os.Remove(a.Dest)
+		}
+		return sc.client(dst).deleteObject(ctx, dst.bucket, dst.prefix+a.rel)
+	}
+	return fmt.Errorf("unknown sync action: %s", a.Action)
+}
+
+// syncDir one-way synchronizes src to dst(local directory or bucket/prefix) with at most
+// concurrency actions in flight. If dryRun is true, the plan is printed and nothing is changed.
+func (sc *S3Cli) syncDir(ctx context.Context, src, dst syncLocation, del, dryRun bool, concurrency int) error {
+	if src.local() && dst.local() {
+		return errors.New("sync between local directories is not supported")
+	}
+	if src.overlaps(dst) {
+		return fmt.Errorf("sync source %s and destination %s overlap", src.path(""), dst.path(""))
+	}
+	if sc.presign {
+		return errors.New("sync can not be presigned")
+	}
+	var err error
+	if src.cli, err = sc.profileCli(src.profile); err != nil {
+		return err
+	}
+	if dst.cli, err = sc.profileCli(dst.profile); err != nil {
+		return err
+	}
+	actions, err := sc.syncPlan(ctx, src, dst, del)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		runWorkers(len(actions), concurrency, func(i int) {
+			if err := ctx.Err(); err != nil {
+				actions[i].Error = err.Error()
+				return
+			}
+			if err := sc.syncDo(ctx, src, dst, actions[i]); err != nil {
+				actions[i].Error = err.Error()
+			}
+		})
+	}
+
+	var failed int
+	for _, a := range actions {
+		if a.Error != "" {
+			failed++
+		}
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(actions, "", "  ")
+		if err != nil {
+			fmt.Println(actions)
+		} else {
+			fmt.Printf("%s", jo)
+		}
+	} else {
+		for _, a := range actions {
+			source := a.Source
+			if source == "" {
+				source = "-"
+			}
+			var line string
+			if sc.lineOutput() {
+				line = fmt.Sprintln(time.Now().Format(time.RFC3339), a.Action, a.Reason, a.Size, source, a.Dest)
+			} else if a.Action == syncDelete {
+				line = fmt.Sprintf("%s %s (%s)", a.Action, a.Dest, a.Reason)
+			} else {
+				line = fmt.Sprintf("%s %s -> %s (%s)", a.Action, source, a.Dest, a.Reason)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if a.Error != "" {
+				line += " " + a.Error
+			}
+			fmt.Println(line)
+		}
+		if sc.verboseOutput() {
+			if dryRun {
+				fmt.Printf("%d action(s) planned\n", len(actions))
+			} else {
+				fmt.Printf("%d action(s) done, %d failed\n", len(actions)-failed, failed)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("sync: %d of %d action(s) failed", failed, len(actions))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_parseSyncLocation(t *testing.T) {
+	setTestProfiles(t, "[profile p1]\n", "")
+	cases := map[string]syncLocation{
+		"./build":            {dir: "./build"},
+		"/tmp/build":         {dir: "/tmp/build"},
+		"bucket":             {bucket: "bucket"},
+		"bucket/dir":         {bucket: "bucket", prefix: "dir/"},
+		"bucket/dir/sub/":    {bucket: "bucket", prefix: "dir/sub/"},
+		"../relative/parent": {dir: "../relative/parent"},
+		"p1:bucket/dir":      {profile: "p1", bucket: "bucket", prefix: "dir/"},
+		"bucket:dir":         {bucket: "bucket:dir"},
+	}
+	for arg, want := range cases {
+		if got := s3cliTest.parseSyncLocation(arg); got != want {
+			t.Errorf("parseSyncLocation(%s) expect: %+v, got: %+v", arg, want, got)
+		}
+	}
+}
+
+func Test_syncReason(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	localFile := filepath.Join(dir, "f")
+	if err := os.WriteFile(localFile, testObjectContent, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	contentMd5 := "265237d306da5fdc63ed220dac25c48c" // md5 of testObjectContent
+	size := int64(len(testObjectContent))
+
+	cases := []struct {
+		s, d syncEntry
+		want string
+	}{
+		{syncEntry{size: 1}, syncEntry{size: 2}, "size"},
+		{syncEntry{size: 1, etag: "a"}, syncEntry{size: 1, etag: "b"}, "etag"},
+		{syncEntry{size: 1, etag: "a"}, syncEntry{size: 1, etag: "a"}, ""},
+		{syncEntry{size: 1, modTime: now}, syncEntry{size: 1, modTime: now.Add(time.Hour)}, ""},
+		{syncEntry{size: 1, modTime: now.Add(time.Hour)}, syncEntry{size: 1, modTime: now, etag: "a-2"}, "mtime"},
+		{syncEntry{size: size, modTime: now.Add(time.Hour)}, syncEntry{size: size, modTime: now, etag: contentMd5}, ""},
+		{syncEntry{size: size, modTime: now.Add(time.Hour)}, syncEntry{size: size, modTime: now, etag: "0123"}, "etag"},
+		// a copy across profiles is a multipart Object, newer than the source
+		{syncEntry{size: size, modTime: now, etag: contentMd5}, syncEntry{size: size, modTime: now.Add(time.Hour), etag: contentMd5 + "-2"}, ""},
+		{syncEntry{size: size, modTime: now.Add(time.Hour), etag: contentMd5}, syncEntry{size: size, modTime: now, etag: contentMd5 + "-2"}, "mtime"},
+		{syncEntry{size: size, modTime: now, etag: contentMd5 + "-2"}, syncEntry{size: size, modTime: now.Add(time.Hour), etag: contentMd5}, ""},
+	}
+	for i, c := range cases {
+		got, err := syncReason(c.s, c.d, localFile)
+		if err != nil {
+			t.Errorf("syncReason case %d failed: %s", i, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("syncReason case %d expect: %q, got: %q", i, c.want, got)
+		}
+	}
+}
+
+func Test_syncDir(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	for _, f := range []string{"a.txt", "sub/b.txt"} {
+		p := filepath.Join(src, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("failed to create test dir: %v", err)
+		}
+		if err := os.WriteFile(p, testObjectContent, 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	bucketDir := syncLocation{bucket: testBucketName, prefix: "syncDir/"}
+	if err := s3cliTest.syncDir(ctx, syncLocation{dir: src}, bucketDir, false, true, 2); err != nil {
+		t.Fatalf("syncDir dry-run failed: %s", err)
+	}
+	if actions, err := s3cliTest.syncPlan(ctx, syncLocation{dir: src}, bucketDir, false); err != nil || len(actions) != 2 {
+		t.Fatalf("syncPlan after dry-run expect 2 actions, got: %v, %v", actions, err)
+	}
+
+	// local to bucket
+	if err := s3cliTest.syncDir(ctx, syncLocation{dir: src}, bucketDir, false, false, 2); err != nil {
+		t.Fatalf("syncDir local to bucket failed: %s", err)
+	}
+	if actions, err := s3cliTest.syncPlan(ctx, syncLocation{dir: src}, bucketDir, false); err != nil || len(actions) != 0 {
+		t.Errorf("syncPlan after sync expect no action, got: %v, %v", actions, err)
+	}
+
+	// bucket to bucket
+	bucketCopy := syncLocation{bucket: testBucketName, prefix: "syncDirCopy/"}
+	if err := s3cliTest.syncDir(ctx, bucketDir, bucketCopy, false, false, 2); err != nil {
+		t.Fatalf("syncDir bucket to bucket failed: %s", err)
+	}
+
+	// overlapping source and destination
+	for _, o := range []syncLocation{{bucket: testBucketName, prefix: "syncDir/backup/"}, {bucket: testBucketName}, bucketDir} {
+		if err := s3cliTest.syncDir(ctx, bucketDir, o, false, true, 2); err == nil {
+			t.Errorf("syncDir %s to %s expect overlap error", bucketDir.path(""), o.path(""))
+		}
+	}
+
+	// bucket to local
+	dst := filepath.Join(t.TempDir(), "dst")
+	if err := s3cliTest.syncDir(ctx, bucketCopy, syncLocation{dir: dst}, false, false, 2); err != nil {
+		t.Fatalf("syncDir bucket to local failed: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "sub", "b.txt")); err != nil {
+		t.Errorf("syncDir bucket to local: %s", err)
+	}
+	if actions, err := s3cliTest.syncPlan(ctx, bucketCopy, syncLocation{dir: dst}, false); err != nil || len(actions) != 0 {
+		t.Errorf("syncPlan after download expect no action, got: %v, %v", actions, err)
+	}
+
+	// delete extraneous Object
+	if err := os.Remove(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatalf("failed to remove test file: %v", err)
+	}
+	if err := s3cliTest.syncDir(ctx, syncLocation{dir: src}, bucketDir, true, false, 2); err != nil {
+		t.Fatalf("syncDir delete failed: %s", err)
+	}
+	if _, err := s3Backend.HeadObject(testBucketName, "syncDir/a.txt"); err == nil {
+		t.Errorf("syncDir extraneous Object not deleted")
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// parseTags parses tags of format Key=Value
+func parseTags(args []string) ([]*s3.Tag, error) {
+	tags := make([]*s3.Tag, 0, len(args))
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid tag %s(format Key=Value)", arg)
+		}
+		tags = append(tags, &s3.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return tags, nil
+}
+
+// loadTags loads tags from a JSON file of format {"TagSet": [{"Key": "k", "Value": "v"}]}
+func loadTags(filename string) ([]*s3.Tag, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	tagging := s3.Tagging{}
+	if err := json.NewDecoder(fd).Decode(&tagging); err != nil {
+		return nil, fmt.Errorf("invalid tags file %s: %w", filename, err)
+	}
+	if err := tagging.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid tags file %s: %w", filename, err)
+	}
+	return tagging.TagSet, nil
+}
+
+// encodeTags encodes tags as URL query parameters(header x-amz-tagging)
+func encodeTags(tags []*s3.Tag) string {
+	values := url.Values{}
+	for _, t := range tags {
+		values.Add(aws.StringValue(t.Key), aws.StringValue(t.Value))
+	}
+	return values.Encode()
+}
+
+// printTags prints tags as Key=Value(simple) or Key<TAB>Value(line) lines, or out as JSON or verbose
+func (sc *S3Cli) printTags(tags []*s3.Tag, out interface{}) {
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			fmt.Println(out)
+			return
+		}
+		fmt.Printf("%s", jo)
+	} else if sc.verboseOutput() {
+		fmt.Println(out)
+	} else {
+		for _, t := range tags {
+			if sc.lineOutput() {
+				fmt.Printf("%s\t%s\n", aws.StringValue(t.Key), aws.StringValue(t.Value))
+			} else {
+				fmt.Printf("%s=%s\n", aws.StringValue(t.Key), aws.StringValue(t.Value))
+			}
+		}
+	}
+}
+
+// objectTags returns the tags of Object bucket/key
+func (sc *S3Cli) objectTags(ctx context.Context, bucket, key, version string) (*s3.GetObjectTaggingOutput, error) {
+	input := &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.GetObjectTaggingRequest(input)
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return nil, fmt.Errorf("get object tagging %s failed: %w", key, err)
+	}
+	return resp, nil
+}
+
+// getObjectTagging print the tags of Object bucket/key
+func (sc *S3Cli) getObjectTagging(ctx context.Context, bucket, key, version string) error {
+	if sc.presign {
+		input := &s3.GetObjectTaggingInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		}
+		if version != "" {
+			input.VersionId = aws.String(version)
+		}
+		req, _ := sc.Client.GetObjectTaggingRequest(input)
+		req.SetContext(ctx)
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+	resp, err := sc.objectTags(ctx, bucket, key, version)
+	if err != nil {
+		return err
+	}
+	sc.printTags(resp.TagSet, resp)
+	return nil
+}
+
+// putObjectTagging replaces the tags of Object bucket/key with tags
+func (sc *S3Cli) putObjectTagging(ctx context.Context, bucket, key, version string, tags []*s3.Tag) error {
+	input := &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &s3.Tagging{TagSet: tags},
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.PutObjectTaggingRequest(input)
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put object tagging %s failed: %w", key, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// deleteObjectTagging removes all tags of Object bucket/key
+func (sc *S3Cli) deleteObjectTagging(ctx context.Context, bucket, key, version string) error {
+	input := &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if version != "" {
+		input.VersionId = aws.String(version)
+	}
+	req, resp := sc.Client.DeleteObjectTaggingRequest(input)
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete object tagging %s failed: %w", key, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// copyTagging returns the tagging(x-amz-tagging) of the copy of Object bucket/key of src with tagCount
+// (unknown if < 0) tags: sc.tagging if sc.tagReplace, otherwise the tags of the Object.
+func (sc *S3Cli) copyTagging(ctx context.Context, src *S3Cli, bucket, key string, tagCount int64) (string, error) {
+	if sc.tagReplace || tagCount == 0 {
+		return sc.tagging, nil
+	}
+	resp, err := src.objectTags(ctx, bucket, key, "")
+	if notImplemented(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return encodeTags(resp.TagSet), nil
+}
+
+// bucketTags returns the tags of bucket, no tags if the Bucket has no tag set
+func (sc *S3Cli) bucketTags(ctx context.Context, bucket string) (*s3.GetBucketTaggingOutput, error) {
+	req, resp := sc.Client.GetBucketTaggingRequest(&s3.GetBucketTaggingInput{
+		Bucket: aws.String(bucket),
+	})
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == "NoSuchTagSet" {
+			return &s3.GetBucketTaggingOutput{TagSet: []*s3.Tag{}}, nil
+		}
+		return nil, fmt.Errorf("get bucket tagging %s failed: %w", bucket, err)
+	}
+	return resp, nil
+}
+
+// getBucketTagging prints the tags of bucket
+func (sc *S3Cli) getBucketTagging(ctx context.Context, bucket string) error {
+	if sc.presign {
+		req, _ := sc.Client.GetBucketTaggingRequest(&s3.GetBucketTaggingInput{
+			Bucket: aws.String(bucket),
+		})
+		req.SetContext(ctx)
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+	resp, err := sc.bucketTags(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	sc.printTags(resp.TagSet, resp)
+	return nil
+}
+
+// putBucketTagging replaces the tags of bucket with tags
+func (sc *S3Cli) putBucketTagging(ctx context.Context, bucket string, tags []*s3.Tag) error {
+	req, resp := sc.Client.PutBucketTaggingRequest(&s3.PutBucketTaggingInput{
+		Bucket:  aws.String(bucket),
+		Tagging: &s3.Tagging{TagSet: tags},
+	})
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("put bucket tagging %s failed: %w", bucket, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
+
+// deleteBucketTagging removes all tags of bucket
+func (sc *S3Cli) deleteBucketTagging(ctx context.Context, bucket string) error {
+	req, resp := sc.Client.DeleteBucketTaggingRequest(&s3.DeleteBucketTaggingInput{
+		Bucket: aws.String(bucket),
+	})
+	req.SetContext(ctx)
+
+	if sc.presign {
+		s, err := req.Presign(sc.presignExp)
+		if err == nil {
+			fmt.Println(s)
+		}
+		return err
+	}
+
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		return fmt.Errorf("delete bucket tagging %s failed: %w", bucket, err)
+	}
+	if sc.verboseOutput() {
+		fmt.Println(resp)
+	}
+	return nil
+}
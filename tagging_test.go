@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/johannesboyne/gofakes3"
+)
+
+func Test_parseTags(t *testing.T) {
+	tags, err := parseTags([]string{"env=prod", "empty=", "k=v=1"})
+	if err != nil {
+		t.Fatalf("parseTags failed: %s", err)
+	}
+	want := []*s3.Tag{
+		{Key: aws.String("env"), Value: aws.String("prod")},
+		{Key: aws.String("empty"), Value: aws.String("")},
+		{Key: aws.String("k"), Value: aws.String("v=1")},
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("parseTags expect: %v, got: %v", want, tags)
+	}
+	for _, v := range []string{"env", "=prod"} {
+		if _, err := parseTags([]string{v}); err == nil {
+			t.Errorf("parseTags(%s) expect error", v)
+		}
+	}
+}
+
+func Test_encodeTags(t *testing.T) {
+	tags, _ := parseTags([]string{"team=a b", "env=prod&dev"})
+	if got, want := encodeTags(tags), "env=prod%26dev&team=a+b"; got != want {
+		t.Errorf("encodeTags expect: %s, got: %s", want, got)
+	}
+}
+
+// taggingTestCli returns a S3Cli of a fake S3 which keeps tags of Buckets and Objects in tagging by URL path
+func taggingTestCli(t *testing.T, tagging map[string]string) S3Cli {
+	// the fake S3 does not support tagging, keep the tags of Put*Tagging and x-amz-tagging
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["tagging"]; !ok {
+			if r.Method == http.MethodPut && r.Header.Get("x-amz-tagging") != "" {
+				tagging[r.URL.Path] = r.Header.Get("x-amz-tagging")
+			}
+			faker.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			v, ok := tagging[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `<Error><Code>NoSuchTagSet</Code><Message>The TagSet does not exist</Message></Error>`)
+				return
+			}
+			values, _ := url.ParseQuery(v)
+			fmt.Fprint(w, `<Tagging><TagSet>`)
+			for k := range values {
+				fmt.Fprintf(w, `<Tag><Key>%s</Key><Value>%s</Value></Tag>`, k, values.Get(k))
+			}
+			fmt.Fprint(w, `</TagSet></Tagging>`)
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			var in struct {
+				Tags []struct{ Key, Value string } `xml:"TagSet>Tag"`
+			}
+			if err := xml.Unmarshal(body, &in); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			values := url.Values{}
+			for _, tag := range in.Tags {
+				values.Set(tag.Key, tag.Value)
+			}
+			tagging[r.URL.Path] = values.Encode()
+		case http.MethodDelete:
+			delete(tagging, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+	return sc
+}
+
+func Test_objectTagging(t *testing.T) {
+	tagging := map[string]string{}
+	sc := taggingTestCli(t, tagging)
+	key := "objectTagging"
+	path := "/" + testBucketName + "/" + key
+
+	tags, _ := parseTags([]string{"env=prod"})
+	sc.tagging = encodeTags(tags)
+	if _, err := sc.sendPutObject(context.Background(), testBucketName, key, "", nil, false, bytes.NewReader(testObjectContent)); err != nil {
+		t.Fatalf("sendPutObject with tagging failed: %s", err)
+	}
+	if tagging[path] != "env=prod" {
+		t.Errorf("sendPutObject expect tagging env=prod, got: %s", tagging[path])
+	}
+
+	tags, _ = parseTags([]string{"team=storage"})
+	if err := sc.putObjectTagging(context.Background(), testBucketName, key, "", tags); err != nil {
+		t.Fatalf("putObjectTagging failed: %s", err)
+	}
+	resp, err := sc.objectTags(context.Background(), testBucketName, key, "")
+	if err != nil {
+		t.Fatalf("objectTags failed: %s", err)
+	}
+	if !reflect.DeepEqual(resp.TagSet, tags) {
+		t.Errorf("objectTags expect: %v, got: %v", tags, resp.TagSet)
+	}
+
+	sc.tagging = ""
+	if got, err := sc.copyTagging(context.Background(), &sc, testBucketName, key, -1); err != nil || got != "team=storage" {
+		t.Errorf("copyTagging expect team=storage, got: %s, %v", got, err)
+	}
+	sc.tagReplace = true
+	if got, err := sc.copyTagging(context.Background(), &sc, testBucketName, key, -1); err != nil || got != "" {
+		t.Errorf("copyTagging REPLACE expect no tags, got: %s, %v", got, err)
+	}
+
+	if err := sc.deleteObjectTagging(context.Background(), testBucketName, key, ""); err != nil {
+		t.Fatalf("deleteObjectTagging failed: %s", err)
+	}
+	if _, ok := tagging[path]; ok {
+		t.Errorf("deleteObjectTagging expect no tags, got: %s", tagging[path])
+	}
+}
+
+func Test_loadTags(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tags.json")
+	os.WriteFile(filename, []byte(`{"TagSet": [{"Key": "cost-center", "Value": "1024"}]}`), 0644)
+	tags, err := loadTags(filename)
+	want, _ := parseTags([]string{"cost-center=1024"})
+	if err != nil || !reflect.DeepEqual(tags, want) {
+		t.Errorf("loadTags expect: %v, got: %v, %v", want, tags, err)
+	}
+	for _, content := range []string{`cost-center=1024`, `{"TagSet": [{"Value": "1024"}]}`} {
+		os.WriteFile(filename, []byte(content), 0644)
+		if _, err := loadTags(filename); err == nil {
+			t.Errorf("loadTags(%s) expect error", content)
+		}
+	}
+}
+
+func Test_bucketTagging(t *testing.T) {
+	tagging := map[string]string{}
+	sc := taggingTestCli(t, tagging)
+
+	resp, err := sc.bucketTags(context.Background(), testBucketName)
+	if err != nil || len(resp.TagSet) != 0 {
+		t.Errorf("bucketTags expect no tags, got: %v, %v", resp, err)
+	}
+	tags, _ := parseTags([]string{"cost-center=1024"})
+	if err := sc.putBucketTagging(context.Background(), testBucketName, tags); err != nil {
+		t.Fatalf("putBucketTagging failed: %s", err)
+	}
+	resp, err = sc.bucketTags(context.Background(), testBucketName)
+	if err != nil || !reflect.DeepEqual(resp.TagSet, tags) {
+		t.Errorf("bucketTags expect: %v, got: %v, %v", tags, resp, err)
+	}
+	if err := sc.deleteBucketTagging(context.Background(), testBucketName); err != nil {
+		t.Fatalf("deleteBucketTagging failed: %s", err)
+	}
+	if _, ok := tagging["/"+testBucketName]; ok {
+		t.Errorf("deleteBucketTagging expect no tags, got: %s", tagging["/"+testBucketName])
+	}
+}
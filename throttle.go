@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiter of bytes per second shared by all transfers
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  int     // max bytes transferred at once
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a rateLimiter of rate bytes per second
+func newRateLimiter(rate int64) *rateLimiter {
+	burst := min(max(rate/10, 1), 1<<20)
+	return &rateLimiter{
+		rate:   float64(rate),
+		burst:  int(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes n tokens and blocks until the bucket is not in debt or ctx is done
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(float64(l.burst), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	var d time.Duration
+	if l.tokens < 0 {
+		d = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limitedReadCloser reads at most the rate of limiter l, it stops waiting when ctx is done
+type limitedReadCloser struct {
+	io.ReadCloser
+	ctx context.Context
+	l   *rateLimiter
+}
+
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+	if len(p) > r.l.burst {
+		p = p[:r.l.burst]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if werr := r.l.wait(r.ctx, n); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
+
+// transferTransport limits the request and response bodies of all requests with a shared rateLimiter
+// (if l is not nil), so the limit applies across concurrent parts and clients.
+// It also counts the uploaded and downloaded bytes to the progress in the request context.
+type transferTransport struct {
+	http.RoundTripper
+	l *rateLimiter
+}
+
+func (t *transferTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := progressFrom(req.Context())
+	if req.Body != nil && req.Body != http.NoBody && (t.l != nil || p != nil) {
+		r := *req
+		r.Body = t.wrap(req.Context(), req.Body, p)
+		req = &r
+	}
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// only the body of GET is the content transferred, others are small XML documents
+	if req.Method != http.MethodGet {
+		p = nil
+	}
+	resp.Body = t.wrap(req.Context(), resp.Body, p)
+	return resp, nil
+}
+
+func (t *transferTransport) wrap(ctx context.Context, body io.ReadCloser, p *progress) io.ReadCloser {
+	if t.l != nil {
+		body = &limitedReadCloser{ReadCloser: body, ctx: ctx, l: t.l}
+	}
+	if p != nil {
+		body = &progressReadCloser{ReadCloser: body, p: p}
+	}
+	return body
+}
+
+// parseRate parses rate such as 1024, 500K, 50MiB/s or 1.5GB/s to bytes per second,
+// units K, M and G are multiples of 1024(like curl --limit-rate).
+func parseRate(rate string) (int64, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(rate), "/s")
+	s = strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+	multiple := 1.0
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			multiple = 1 << 10
+		case 'M':
+			multiple = 1 << 20
+		case 'G':
+			multiple = 1 << 30
+		}
+		if multiple > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v*multiple < 1 {
+		return 0, fmt.Errorf("invalid rate %s", rate)
+	}
+	return int64(v * multiple), nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func Test_parseRate(t *testing.T) {
+	cases := map[string]int64{
+		"1024":     1024,
+		"500K":     500 << 10,
+		"500k":     500 << 10,
+		"50MiB/s":  50 << 20,
+		"50MB/s":   50 << 20,
+		"1.5G":     3 << 29,
+		"":         0,
+		"fast":     0,
+		"0":        0,
+		"-1M":      0,
+		"10 MiB/s": 10 << 20,
+	}
+	for rate, want := range cases {
+		got, err := parseRate(rate)
+		if want == 0 {
+			if err == nil {
+				t.Errorf("parseRate(%s) expect error, got: %d", rate, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("parseRate(%s) expect: %d, got: %d, %v", rate, want, got, err)
+		}
+	}
+}
+
+func Test_rateLimiter(t *testing.T) {
+	l := newRateLimiter(1 << 20) // 1MiB/s, burst 100KiB
+	data := make([]byte, 300<<10)
+	start := time.Now()
+	// 2 readers share the limit
+	done := make(chan int64, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			r := &limitedReadCloser{ReadCloser: io.NopCloser(bytes.NewReader(data)), ctx: context.Background(), l: l}
+			n, _ := io.Copy(io.Discard, r)
+			done <- n
+		}()
+	}
+	total := <-done + <-done
+	if total != int64(2*len(data)) {
+		t.Errorf("rateLimiter expect %d bytes, got: %d", 2*len(data), total)
+	}
+	// (600KiB - 100KiB burst) / 1MiB/s
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("rateLimiter expect at least 400ms, got: %s", elapsed)
+	}
+}
+
+func Test_rateLimiterCancel(t *testing.T) {
+	l := newRateLimiter(1 << 10) // 1KiB/s
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	r := &limitedReadCloser{ReadCloser: io.NopCloser(bytes.NewReader(make([]byte, 100<<10))), ctx: ctx, l: l}
+	start := time.Now()
+	if _, err := io.Copy(io.Discard, r); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("rateLimiter expect error %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("rateLimiter expect to stop after the context is done, got: %s", elapsed)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	verifyMatch    = "match"
+	verifyMismatch = "mismatch"
+	verifyMissing  = "missing"
+	verifyFailed   = "failed"
+)
+
+// commonPartSizes are part sizes(MiB) of popular uploaders tried when the part size of a multipart ETag is unknown
+var commonPartSizes = []int64{5, 8, 15, 16, 32, 64, 100, 128, 256, 512, 1024}
+
+// verifyResult is the result of verifying a local file against its Object
+type verifyResult struct {
+	File       string `json:"file"`
+	Key        string `json:"key"`
+	Size       int64  `json:"size"`
+	LocalETag  string `json:"localEtag,omitempty"`
+	RemoteETag string `json:"remoteEtag,omitempty"`
+	Status     string `json:"status"` // match, mismatch, missing or failed
+	Error      string `json:"error,omitempty"`
+}
+
+// parseETag splits a ETag to the hex MD5 and the number of parts(0 if it is not a multipart ETag),
+// ok is false if it is not a MD5 ETag(e.g. Object encrypted with SSE-KMS).
+func parseETag(etag string) (sum string, parts int, ok bool) {
+	sum = strings.Trim(etag, `"`)
+	if i := strings.LastIndex(sum, "-"); i >= 0 {
+		n, err := strconv.Atoi(sum[i+1:])
+		if err != nil || n < 1 {
+			return "", 0, false
+		}
+		sum, parts = sum[:i], n
+	}
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != 2*md5.Size {
+		return "", 0, false
+	}
+	return sum, parts, true
+}
+
+// fileETags computes the ETags of the content of r uploaded with a single PUT(part size 0), or with mpu
+// in parts of each of partSizes bytes: MD5 of the concatenated part MD5s and the number of parts.
+// r is read only once for all part sizes.
+func fileETags(r io.Reader, partSizes []int64) ([]string, error) {
+	type partHash struct {
+		size    int64     // part size, 0 for a single PUT
+		written int64     // bytes written to part
+		part    hash.Hash // MD5 of current part
+		sums    hash.Hash // MD5 of part MD5s
+		parts   int
+	}
+	hashes := make([]*partHash, len(partSizes))
+	for i, size := range partSizes {
+		hashes[i] = &partHash{size: size, part: md5.New(), sums: md5.New()}
+	}
+	buf := make([]byte, 1<<20)
+	for {
+		n, err := io.ReadFull(r, buf)
+		for _, h := range hashes {
+			data := buf[:n]
+			for len(data) > 0 {
+				k := len(data)
+				if h.size > 0 {
+					k = int(min(int64(k), h.size-h.written))
+				}
+				h.part.Write(data[:k])
+				h.written += int64(k)
+				data = data[k:]
+				if h.written == h.size {
+					h.sums.Write(h.part.Sum(nil))
+					h.part.Reset()
+					h.written = 0
+					h.parts++
+				}
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	etags := make([]string, len(hashes))
+	for i, h := range hashes {
+		if h.size <= 0 {
+			etags[i] = fmt.Sprintf(`"%x"`, h.part.Sum(nil))
+			continue
+		}
+		if h.written > 0 || h.parts == 0 {
+			h.sums.Write(h.part.Sum(nil))
+			h.parts++
+		}
+		etags[i] = fmt.Sprintf(`"%x-%d"`, h.sums.Sum(nil), h.parts)
+	}
+	return etags, nil
+}
+
+// partSizeCandidates returns the part sizes that split size bytes to parts parts, the part size of
+// the Object(HeadObject with PartNumber 1) or partSize if given, otherwise the smallest MiB multiple
+// and the common part sizes.
+func (sc *S3Cli) partSizeCandidates(ctx context.Context, bucket, key string, size int64, parts int, partSize int64) []int64 {
+	if partSize > 0 {
+		return []int64{partSize}
+	}
+	req, resp := sc.Client.HeadObjectRequest(&s3.HeadObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(key),
+		PartNumber: aws.Int64(1),
+	})
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err == nil && aws.Int64Value(resp.PartsCount) == int64(parts) &&
+		aws.Int64Value(resp.ContentLength) > 0 && aws.Int64Value(resp.ContentLength) < size {
+		return []int64{aws.Int64Value(resp.ContentLength)}
+	}
+
+	fits := func(p int64) bool {
+		return p >= 1 && max((size+p-1)/p, 1) == int64(parts)
+	}
+	var candidates []int64
+	p := (size + int64(parts) - 1) / int64(parts)
+	if mib := (p + 1<<20 - 1) >> 20 << 20; fits(mib) {
+		candidates = append(candidates, mib)
+	}
+	if fits(p) && !slices.Contains(candidates, p) {
+		candidates = append(candidates, p)
+	}
+	for _, p := range commonPartSizes {
+		if fits(p<<20) && !slices.Contains(candidates, p<<20) {
+			candidates = append(candidates, p<<20)
+		}
+	}
+	return candidates
+}
+
+// verifyFile compares the ETag of local file filename(single part MD5 or multipart composite) with
+// the ETag of Object bucket/key. The part size of a multipart Object is partSize if it is not 0,
+// otherwise it is inferred from the Object.
+func (sc *S3Cli) verifyFile(ctx context.Context, bucket, key, filename string, partSize int64) verifyResult {
+	result := verifyResult{File: filename, Key: key}
+	fail := func(err error) verifyResult {
+		result.Status, result.Error = verifyFailed, err.Error()
+		return result
+	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		return fail(err)
+	}
+	defer fd.Close()
+	info, err := fd.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	result.Size = info.Size()
+
+	req, resp := sc.Client.HeadObjectRequest(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	req.SetContext(ctx)
+	sc.addCustomHeader(req.HTTPRequest)
+	if err := req.Send(); err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && (aerr.Code() == "NotFound" || aerr.Code() == s3.ErrCodeNoSuchKey) {
+			result.Status = verifyMissing
+			return result
+		}
+		return fail(fmt.Errorf("head object %s failed: %w", key, err))
+	}
+	result.RemoteETag = aws.StringValue(resp.ETag)
+	if size := aws.Int64Value(resp.ContentLength); size != result.Size {
+		result.Status = verifyMismatch
+		result.Error = fmt.Sprintf("size %d, Object size %d", result.Size, size)
+		return result
+	}
+	_, parts, ok := parseETag(result.RemoteETag)
+	if !ok {
+		return fail(fmt.Errorf("ETag %s is not a MD5 ETag", result.RemoteETag))
+	}
+
+	candidates := []int64{0}
+	if parts > 0 {
+		candidates = sc.partSizeCandidates(ctx, bucket, key, result.Size, parts, partSize)
+		if len(candidates) == 0 {
+			return fail(fmt.Errorf("can not infer the part size of %d parts(use --part-size)", parts))
+		}
+	}
+	etags, err := fileETags(fd, candidates)
+	if err != nil {
+		return fail(err)
+	}
+	result.LocalETag = etags[0]
+	result.Status = verifyMismatch
+	// some S3 compatible services respond the ETag without quotes
+	remote := strings.Trim(result.RemoteETag, `"`)
+	if i := slices.IndexFunc(etags, func(etag string) bool { return strings.Trim(etag, `"`) == remote }); i >= 0 {
+		result.LocalETag = etags[i]
+		result.Status = verifyMatch
+	}
+	return result
+}
+
+// verify compares local files with Objects and reports mismatches. If path is a file and key is
+// not a prefix(empty or end with '/'), it is verified against bucket/key, otherwise all files
+// under path are verified against bucket/key + relative path with at most concurrency files in flight.
+func (sc *S3Cli) verify(ctx context.Context, bucket, key, path string, wo walkOptions, partSize int64, concurrency int) error {
+	if sc.presign {
+		return errors.New("verify can not be presigned")
+	}
+	files, err := walkLocalFiles(path, wo)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	single := !info.IsDir() && key != "" && !strings.HasSuffix(key, "/")
+	if !single && key != "" && !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+
+	results := make([]verifyResult, len(files))
+	runWorkers(len(files), concurrency, func(i int) {
+		k := key + files[i].rel
+		if single {
+			k = key
+		}
+		if err := ctx.Err(); err != nil {
+			results[i] = verifyResult{File: files[i].path, Key: k, Status: verifyFailed, Error: err.Error()}
+			return
+		}
+		results[i] = sc.verifyFile(ctx, bucket, k, files[i].path, partSize)
+	})
+
+	var bad int
+	for _, r := range results {
+		if r.Status != verifyMatch {
+			bad++
+		}
+	}
+	if sc.jsonOutput() {
+		jo, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Println(results)
+		} else {
+			fmt.Printf("%s", jo)
+		}
+	} else {
+		for _, r := range results {
+			if sc.lineOutput() {
+				fmt.Println(time.Now().Format(time.RFC3339), r.Status, r.Size, r.File, r.Key, r.LocalETag, r.RemoteETag, r.Error)
+			} else if r.Error != "" {
+				fmt.Printf("%s %s -> %s: %s\n", r.Status, r.File, r.Key, r.Error)
+			} else if r.Status == verifyMismatch {
+				fmt.Printf("%s %s -> %s: ETag %s, Object ETag %s\n", r.Status, r.File, r.Key, r.LocalETag, r.RemoteETag)
+			} else {
+				fmt.Printf("%s %s -> %s\n", r.Status, r.File, r.Key)
+			}
+		}
+		if sc.verboseOutput() {
+			fmt.Printf("%d file(s) match, %d not\n", len(results)-bad, bad)
+		}
+	}
+	if bad > 0 {
+		return fmt.Errorf("verify %s: %d of %d file(s) not match", path, bad, len(results))
+	}
+	return nil
+}
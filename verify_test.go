@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+)
+
+func Test_parseETag(t *testing.T) {
+	cases := []struct {
+		etag  string
+		sum   string
+		parts int
+		ok    bool
+	}{
+		{`"d41d8cd98f00b204e9800998ecf8427e"`, "d41d8cd98f00b204e9800998ecf8427e", 0, true},
+		{`"d41d8cd98f00b204e9800998ecf8427e-12"`, "d41d8cd98f00b204e9800998ecf8427e", 12, true},
+		{`d41d8cd98f00b204e9800998ecf8427e`, "d41d8cd98f00b204e9800998ecf8427e", 0, true},
+		{`"d41d8cd98f00b204e9800998ecf8427e-x"`, "", 0, false},
+		{`"kms-encrypted"`, "", 0, false},
+		{`""`, "", 0, false},
+	}
+	for _, c := range cases {
+		sum, parts, ok := parseETag(c.etag)
+		if sum != c.sum || parts != c.parts || ok != c.ok {
+			t.Errorf("parseETag(%s) expect: %s %d %v, got: %s %d %v", c.etag, c.sum, c.parts, c.ok, sum, parts, ok)
+		}
+	}
+}
+
+func Test_fileETags(t *testing.T) {
+	content := bytes.Repeat(testObjectContent, 2) // 36 bytes
+	sum := func(b ...[]byte) []byte {
+		h := md5.New()
+		for _, v := range b {
+			h.Write(v)
+		}
+		return h.Sum(nil)
+	}
+	partSizes := []int64{0, 16, 36, 5}
+	want := []string{
+		fmt.Sprintf(`"%x"`, sum(content)),
+		fmt.Sprintf(`"%x-3"`, sum(sum(content[:16]), sum(content[16:32]), sum(content[32:]))),
+		fmt.Sprintf(`"%x-1"`, sum(sum(content))),
+	}
+	var sums [][]byte
+	for i := 0; i < len(content); i += 5 {
+		sums = append(sums, sum(content[i:min(i+5, len(content))]))
+	}
+	want = append(want, fmt.Sprintf(`"%x-8"`, sum(sums...)))
+	if got, err := fileETags(bytes.NewReader(content), partSizes); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("fileETags(%d, %v) expect: %v, got: %v, %v", len(content), partSizes, want, got, err)
+	}
+	want = []string{fmt.Sprintf(`"%x"`, sum()), fmt.Sprintf(`"%x-1"`, sum(sum()))}
+	if got, err := fileETags(bytes.NewReader(nil), []int64{0, 16}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("fileETags(0, [0 16]) expect: %v, got: %v, %v", want, got, err)
+	}
+}
+
+func Test_verify(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	files := map[string][]byte{
+		testObjectKey: testObjectContent,
+		"changed":     []byte("local content"),
+		"missing":     []byte("not uploaded"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+	if _, err := s3Backend.PutObject(testBucketName, "verify/changed", nil, bytes.NewReader([]byte("local-content")), 13, nil); err != nil {
+		t.Fatalf("verify backend PutObject failed: %s", err)
+	}
+	if _, err := s3Backend.PutObject(testBucketName, "verify/"+testObjectKey, nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil); err != nil {
+		t.Fatalf("verify backend PutObject failed: %s", err)
+	}
+
+	want := map[string]string{
+		testObjectKey: verifyMatch,
+		"changed":     verifyMismatch,
+		"missing":     verifyMissing,
+	}
+	for name, status := range want {
+		r := s3cliTest.verifyFile(ctx, testBucketName, "verify/"+name, filepath.Join(dir, name), 0)
+		if r.Status != status {
+			t.Errorf("verifyFile %s expect: %s, got: %s %s", name, status, r.Status, r.Error)
+		}
+	}
+
+	if err := s3cliTest.verify(ctx, testBucketName, "verify/"+testObjectKey, filepath.Join(dir, testObjectKey), walkOptions{}, 0, 1); err != nil {
+		t.Errorf("verify file failed: %s", err)
+	}
+	if err := s3cliTest.verify(ctx, testBucketName, "verify", dir, walkOptions{}, 0, 2); err == nil {
+		t.Errorf("verify dir expect error of mismatch")
+	}
+	if err := s3cliTest.verify(ctx, testBucketName, "verify/", dir, walkOptions{include: []string{testObjectKey}}, 0, 2); err != nil {
+		t.Errorf("verify dir failed: %s", err)
+	}
+}
+
+func Test_verifyUnquotedETag(t *testing.T) {
+	// the endpoint responds the ETag without quotes
+	faker := gofakes3.New(s3Backend).Server()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		faker.ServeHTTP(rec, r)
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.Header().Set("ETag", strings.Trim(rec.Header().Get("ETag"), `"`))
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	defer ts.Close()
+	sc := s3cliTest
+	sc.endpoint = ts.URL
+	client, err := newS3Client(&sc)
+	if err != nil {
+		t.Fatalf("newS3Client failed: %s", err)
+	}
+	sc.Client = client
+	filename := filepath.Join(t.TempDir(), testObjectKey)
+	if err := os.WriteFile(filename, testObjectContent, 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if _, err := s3Backend.PutObject(testBucketName, "verify/unquoted", nil, bytes.NewReader(testObjectContent), int64(len(testObjectContent)), nil); err != nil {
+		t.Fatalf("verify backend PutObject failed: %s", err)
+	}
+	r := sc.verifyFile(context.Background(), testBucketName, "verify/unquoted", filename, 0)
+	if r.Status != verifyMatch || strings.Contains(r.RemoteETag, `"`) {
+		t.Errorf("verifyFile of unquoted ETag expect: %s, got: %s %s %s", verifyMatch, r.Status, r.RemoteETag, r.Error)
+	}
+}
+
+func Test_partSizeCandidates(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		size     int64
+		parts    int
+		partSize int64
+		want     []int64
+	}{
+		{36, 3, 16, []int64{16}},
+		{36, 3, 0, []int64{12}},
+		{100 << 20, 7, 0, []int64{15 << 20, 100<<20/7 + 1, 16 << 20}},
+		{100 << 20, 13, 0, []int64{8 << 20, 100<<20/13 + 1}},
+		{36, 40, 0, nil},
+	}
+	for _, c := range cases {
+		got := s3cliTest.partSizeCandidates(ctx, testBucketName, testObjectKey, c.size, c.parts, c.partSize)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("partSizeCandidates(%d, %d, %d) expect: %v, got: %v", c.size, c.parts, c.partSize, c.want, got)
+		}
+	}
+}